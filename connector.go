@@ -4,7 +4,14 @@ import (
 	"context"
 	"database/sql/driver"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/caretdev/go-irisnative/src/connection"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
 )
 
 // Connector represents a fixed configuration for the pq driver with a given
@@ -15,8 +22,152 @@ import (
 // See https://golang.org/pkg/database/sql/driver/#Connector.
 // See https://golang.org/pkg/database/sql/#OpenDB.
 type Connector struct {
-	opts   values
+	opts values
 	// dialer Dialer
+	location  *time.Location
+	naiveTime bool
+	charset   encoding.Encoding
+	registry  *connection.Registry
+	// generatedKeys fetches the keys generated by INSERT statements
+	generatedKeys bool
+	// streamLOBs returns large stream columns as *connection.Stream
+	streamLOBs bool
+	// streamThreshold is the size above which string and []byte parameters
+	// are uploaded as streams
+	streamThreshold int
+	legacyTypes     bool
+	listColumns     bool
+	expandSlices    bool
+	// connect holds the feature options and client identity sent to the
+	// server
+	connect connection.ConnectOptions
+}
+
+// ConnectorOption configures a Connector beyond what the DSN provides.
+// Options take precedence over the corresponding DSN parameters.
+type ConnectorOption func(*Connector)
+
+// WithLocation sets the time zone IRIS timestamps are expressed in, like the
+// "timezone" DSN parameter.
+func WithLocation(loc *time.Location) ConnectorOption {
+	return func(c *Connector) {
+		c.location = loc
+	}
+}
+
+// WithNaiveTime treats IRIS timestamps as wall-clock times, like the
+// "naive_time" DSN parameter.
+func WithNaiveTime(naive bool) ConnectorOption {
+	return func(c *Connector) {
+		c.naiveTime = naive
+	}
+}
+
+// WithCharset sets the character set of 8-bit strings for servers without
+// Unicode support, like the "charset" DSN parameter. By default it is derived
+// from the server locale.
+func WithCharset(charset encoding.Encoding) ConnectorOption {
+	return func(c *Connector) {
+		c.charset = charset
+	}
+}
+
+// WithRegistry sets the registry of custom conversions used by the
+// connections: its encoders convert parameters of application types and its
+// decoders the columns of the registered SQL types.
+func WithRegistry(registry *connection.Registry) ConnectorOption {
+	return func(c *Connector) {
+		c.registry = registry
+	}
+}
+
+// WithGeneratedKeys makes INSERT statements fetch the keys they generate, for
+// LastInsertId, like the "generated_keys" DSN parameter.
+func WithGeneratedKeys(fetch bool) ConnectorOption {
+	return func(c *Connector) {
+		c.generatedKeys = fetch
+	}
+}
+
+// WithStreamLOBs returns stream columns larger than one chunk as lazily read
+// *connection.Stream values, like the "stream_lobs" DSN parameter.
+func WithStreamLOBs(stream bool) ConnectorOption {
+	return func(c *Connector) {
+		c.streamLOBs = stream
+	}
+}
+
+// WithStreamThreshold uploads string and []byte parameters larger than bytes
+// as streams, like the "stream_threshold" DSN parameter. 0 disables it.
+func WithStreamThreshold(bytes int) ConnectorOption {
+	return func(c *Connector) {
+		c.streamThreshold = bytes
+	}
+}
+
+// WithLegacyTypes returns int and float32 for narrow integer and FLOAT/REAL
+// columns, like the "legacy_types" DSN parameter.
+func WithLegacyTypes(legacy bool) ConnectorOption {
+	return func(c *Connector) {
+		c.legacyTypes = legacy
+	}
+}
+
+// WithListColumns decodes string columns holding a $LIST into
+// []interface{}, like the "list_columns" DSN parameter.
+func WithListColumns(decode bool) ConnectorOption {
+	return func(c *Connector) {
+		c.listColumns = decode
+	}
+}
+
+// WithSliceExpansion controls whether slices bound to IN (?) are expanded
+// into one parameter per element, like the "expand_slices" DSN parameter.
+func WithSliceExpansion(expand bool) ConnectorOption {
+	return func(c *Connector) {
+		c.expandSlices = expand
+	}
+}
+
+// WithFeatureOptions sets the features requested from the server, like the
+// "feature_options" DSN parameter. Leaving out connection.OptionFastSelect
+// works around server-side fast select issues.
+func WithFeatureOptions(options connection.FeatureOption) ConnectorOption {
+	return func(c *Connector) {
+		c.connect.FeatureOptions = &options
+	}
+}
+
+// WithApplicationName sets the application name the server shows for the
+// connections, like the "application_name" DSN parameter.
+func WithApplicationName(name string) ConnectorOption {
+	return func(c *Connector) {
+		c.connect.ApplicationName = name
+	}
+}
+
+// WithMachineName sets the client machine name sent to the server, like the
+// "machine_name" DSN parameter. It defaults to the host name.
+func WithMachineName(name string) ConnectorOption {
+	return func(c *Connector) {
+		c.connect.MachineName = name
+	}
+}
+
+// WithOSUser sets the client OS user sent to the server, like the "os_user"
+// DSN parameter. It defaults to the current user.
+func WithOSUser(user string) ConnectorOption {
+	return func(c *Connector) {
+		c.connect.OSUser = user
+	}
+}
+
+// WithEventClass sets the event class of the connections, like the
+// "event_class" DSN parameter.
+func WithEventClass(class string) ConnectorOption {
+	return func(c *Connector) {
+		c.connect.EventClass = class
+	}
 }
 
 // Connect returns a connection to the database using the fixed configuration
@@ -37,7 +188,7 @@ func (c *Connector) Driver() driver.Driver {
 //
 // See https://golang.org/pkg/database/sql/driver/#Connector.
 // See https://golang.org/pkg/database/sql/#OpenDB.
-func NewConnector(dsn string) (*Connector, error) {
+func NewConnector(dsn string, options ...ConnectorOption) (*Connector, error) {
 	var err error
 	o := make(values)
 
@@ -65,7 +216,92 @@ func NewConnector(dsn string) (*Connector, error) {
 	}
 	o["client_encoding"] = "UTF8"
 
-	return &Connector{opts: o, /*dialer: defaultDialer{}*/}, nil
+	c := &Connector{
+		opts:            o, /*dialer: defaultDialer{}*/
+		streamThreshold: connection.DefaultStreamThreshold,
+		expandSlices:    true,
+	}
+	c.connect.ApplicationName = o["application_name"]
+	c.connect.MachineName = o["machine_name"]
+	c.connect.OSUser = o["os_user"]
+	c.connect.EventClass = o["event_class"]
+
+	if tz, ok := o["timezone"]; ok {
+		if _, ok := o["loc"]; ok {
+			return nil, errors.New("timezone and loc are aliases; set only one of them")
+		}
+		o["loc"] = tz
+	}
+	if tz, ok := o["loc"]; ok && tz != "" {
+		if c.location, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+	}
+
+	if naive, ok := o["naive_time"]; ok {
+		if c.naiveTime, err = strconv.ParseBool(naive); err != nil {
+			return nil, fmt.Errorf("invalid naive_time %q: %w", naive, err)
+		}
+	}
+
+	if keys, ok := o["generated_keys"]; ok {
+		if c.generatedKeys, err = strconv.ParseBool(keys); err != nil {
+			return nil, fmt.Errorf("invalid generated_keys %q: %w", keys, err)
+		}
+	}
+
+	if stream, ok := o["stream_lobs"]; ok {
+		if c.streamLOBs, err = strconv.ParseBool(stream); err != nil {
+			return nil, fmt.Errorf("invalid stream_lobs %q: %w", stream, err)
+		}
+	}
+
+	if threshold, ok := o["stream_threshold"]; ok {
+		if c.streamThreshold, err = strconv.Atoi(threshold); err != nil {
+			return nil, fmt.Errorf("invalid stream_threshold %q: %w", threshold, err)
+		}
+		if c.streamThreshold < 0 {
+			return nil, fmt.Errorf("invalid stream_threshold %q: must not be negative", threshold)
+		}
+	}
+
+	if legacy, ok := o["legacy_types"]; ok {
+		if c.legacyTypes, err = strconv.ParseBool(legacy); err != nil {
+			return nil, fmt.Errorf("invalid legacy_types %q: %w", legacy, err)
+		}
+	}
+
+	if lists, ok := o["list_columns"]; ok {
+		if c.listColumns, err = strconv.ParseBool(lists); err != nil {
+			return nil, fmt.Errorf("invalid list_columns %q: %w", lists, err)
+		}
+	}
+
+	if expand, ok := o["expand_slices"]; ok {
+		if c.expandSlices, err = strconv.ParseBool(expand); err != nil {
+			return nil, fmt.Errorf("invalid expand_slices %q: %w", expand, err)
+		}
+	}
+
+	if name, ok := o["charset"]; ok && name != "" {
+		if c.charset, err = ianaindex.IANA.Encoding(name); err != nil || c.charset == nil {
+			return nil, fmt.Errorf("invalid charset %q", name)
+		}
+	}
+
+	if features, ok := o["feature_options"]; ok {
+		options, err := connection.ParseFeatureOptions(features)
+		if err != nil {
+			return nil, fmt.Errorf("invalid feature_options %q: %w", features, err)
+		}
+		c.connect.FeatureOptions = &options
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c, nil
 }
 
 // isUTF8 returns whether name is a fuzzy variation of the string "UTF-8".
@@ -0,0 +1,181 @@
+package intersystems
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/caretdev/go-irisnative/src/connection"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestConnectorGeneratedKeys(t *testing.T) {
+	c, err := NewConnector("host=localhost")
+	require.NoError(t, err)
+	assert.False(t, c.generatedKeys)
+	c, err = NewConnector("iris://localhost/USER?generated_keys=true")
+	require.NoError(t, err)
+	assert.True(t, c.generatedKeys)
+	c, err = NewConnector("generated_keys=true", WithGeneratedKeys(false))
+	require.NoError(t, err)
+	assert.False(t, c.generatedKeys)
+	_, err = NewConnector("generated_keys=sometimes")
+	assert.Error(t, err)
+}
+
+func TestConnectorResultOptions(t *testing.T) {
+	c, err := NewConnector("host=localhost")
+	require.NoError(t, err)
+	assert.False(t, c.streamLOBs)
+	assert.Equal(t, connection.DefaultStreamThreshold, c.streamThreshold)
+	assert.False(t, c.legacyTypes)
+	assert.False(t, c.listColumns)
+	assert.True(t, c.expandSlices)
+
+	c, err = NewConnector("iris://localhost/USER?stream_lobs=true&stream_threshold=0" +
+		"&legacy_types=1&list_columns=true&expand_slices=false")
+	require.NoError(t, err)
+	assert.True(t, c.streamLOBs)
+	assert.Equal(t, 0, c.streamThreshold)
+	assert.True(t, c.legacyTypes)
+	assert.True(t, c.listColumns)
+	assert.False(t, c.expandSlices)
+
+	c, err = NewConnector("stream_lobs=true stream_threshold=0",
+		WithStreamLOBs(false), WithStreamThreshold(1000), WithLegacyTypes(true),
+		WithListColumns(true), WithSliceExpansion(false))
+	require.NoError(t, err)
+	assert.False(t, c.streamLOBs)
+	assert.Equal(t, 1000, c.streamThreshold)
+	assert.True(t, c.legacyTypes)
+	assert.True(t, c.listColumns)
+	assert.False(t, c.expandSlices)
+
+	for _, dsn := range []string{
+		"stream_lobs=lazy",
+		"stream_threshold=big",
+		"stream_threshold=-1",
+		"legacy_types=old",
+		"list_columns=maybe",
+		"expand_slices=2",
+	} {
+		_, err = NewConnector(dsn)
+		assert.Error(t, err, dsn)
+	}
+}
+
+func TestConnectorTimezone(t *testing.T) {
+	c, err := NewConnector("host=localhost timezone=UTC naive_time=true")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, c.location)
+	assert.True(t, c.naiveTime)
+
+	c, err = NewConnector("iris://localhost/USER?loc=Local")
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, c.location)
+
+	local := time.FixedZone("UTC+2", 2*60*60)
+	c, err = NewConnector("timezone=UTC", WithLocation(local), WithNaiveTime(true))
+	require.NoError(t, err)
+	assert.Equal(t, local, c.location)
+	assert.True(t, c.naiveTime)
+
+	_, err = NewConnector("iris://localhost/USER?timezone=UTC&loc=Local")
+	assert.Error(t, err)
+	_, err = NewConnector("timezone=Nowhere/Unknown")
+	assert.Error(t, err)
+	_, err = NewConnector("naive_time=maybe")
+	assert.Error(t, err)
+}
+
+func TestConnectorCharset(t *testing.T) {
+	c, err := NewConnector("host=localhost charset=windows-1251")
+	require.NoError(t, err)
+	assert.Equal(t, charmap.Windows1251, c.charset)
+
+	c, err = NewConnector("iris://localhost/USER?charset=ISO-8859-2")
+	require.NoError(t, err)
+	assert.Equal(t, charmap.ISO8859_2, c.charset)
+
+	c, err = NewConnector("charset=windows-1251", WithCharset(charmap.ISO8859_5))
+	require.NoError(t, err)
+	assert.Equal(t, charmap.ISO8859_5, c.charset)
+
+	c, err = NewConnector("host=localhost")
+	require.NoError(t, err)
+	assert.Nil(t, c.charset)
+
+	_, err = NewConnector("charset=klingon")
+	assert.Error(t, err)
+}
+
+func TestConnectorRegistry(t *testing.T) {
+	registry := connection.NewRegistry()
+	c, err := NewConnector("host=localhost", WithRegistry(registry))
+	require.NoError(t, err)
+	assert.Same(t, registry, c.registry)
+
+	type point struct{ X, Y int }
+	registry.RegisterEncoder(point{}, func(value interface{}) (interface{}, error) {
+		p := value.(point)
+		return fmt.Sprintf("%d,%d", p.X, p.Y), nil
+	})
+	var cn conn
+	cn.c.SetRegistry(registry)
+	nv := driver.NamedValue{Ordinal: 1, Value: point{1, 2}}
+	require.NoError(t, cn.CheckNamedValue(&nv))
+	assert.Equal(t, "1,2", nv.Value)
+
+	nv.Value = decimal.RequireFromString("1.50")
+	require.NoError(t, cn.CheckNamedValue(&nv))
+	assert.Equal(t, decimal.RequireFromString("1.50"), nv.Value)
+
+	nv.Value = map[string]int{}
+	assert.ErrorIs(t, cn.CheckNamedValue(&nv), connection.ErrUnsupportedType)
+}
+
+func TestConnectorFeatureOptions(t *testing.T) {
+	c, err := NewConnector("host=localhost")
+	require.NoError(t, err)
+	assert.Nil(t, c.connect.FeatureOptions)
+
+	c, err = NewConnector("iris://localhost/USER?feature_options=durable_transactions,redirect_output")
+	require.NoError(t, err)
+	assert.Equal(t, connection.OptionDurableTransactions|connection.OptionRedirectOutput, *c.connect.FeatureOptions)
+
+	c, err = NewConnector("feature_options=none")
+	require.NoError(t, err)
+	assert.Equal(t, connection.OptionNone, *c.connect.FeatureOptions)
+
+	c, err = NewConnector("feature_options=none", WithFeatureOptions(connection.OptionFastSelect))
+	require.NoError(t, err)
+	assert.Equal(t, connection.OptionFastSelect, *c.connect.FeatureOptions)
+
+	_, err = NewConnector("feature_options=fast_everything")
+	assert.Error(t, err)
+}
+
+func TestConnectorIdentity(t *testing.T) {
+	c, err := NewConnector("host=localhost")
+	require.NoError(t, err)
+	assert.Equal(t, connection.ConnectOptions{}, c.connect)
+
+	c, err = NewConnector("iris://localhost/USER?application_name=billing&machine_name=node-1&os_user=svc&event_class=App.Events")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", c.connect.ApplicationName)
+	assert.Equal(t, "node-1", c.connect.MachineName)
+	assert.Equal(t, "svc", c.connect.OSUser)
+	assert.Equal(t, "App.Events", c.connect.EventClass)
+
+	c, err = NewConnector("application_name=billing",
+		WithApplicationName("orders"), WithMachineName("node-2"), WithOSUser("orders"), WithEventClass("Orders.Events"))
+	require.NoError(t, err)
+	assert.Equal(t, "orders", c.connect.ApplicationName)
+	assert.Equal(t, "node-2", c.connect.MachineName)
+	assert.Equal(t, "orders", c.connect.OSUser)
+	assert.Equal(t, "Orders.Events", c.connect.EventClass)
+}
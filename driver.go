@@ -10,6 +10,7 @@ import (
 	"unicode"
 
 	"github.com/caretdev/go-irisnative/src/connection"
+	"github.com/caretdev/go-irisnative/src/list"
 )
 
 var (
@@ -60,7 +61,7 @@ func (c *Connector) open(ctx context.Context) (cn *conn, err error) {
 
 	cn = &conn{}
 
-	cn.c, err = connection.Connect(addr, namespace, login, password)
+	cn.c, err = connection.ConnectWithOptions(addr, namespace, login, password, c.connect)
 	if err != nil {
 		return nil, err
 	}
@@ -83,9 +84,50 @@ func (c *Connector) open(ctx context.Context) (cn *conn, err error) {
 		}
 	}
 
+	if c.location != nil {
+		cn.c.SetLocation(c.location)
+	}
+	cn.c.SetNaiveTime(c.naiveTime)
+	if c.charset != nil {
+		cn.c.SetCharset(list.NewCharset(c.charset))
+	}
+	cn.c.SetRegistry(c.registry)
+	cn.c.SetGeneratedKeys(c.generatedKeys)
+	cn.c.SetStreamLOBs(c.streamLOBs)
+	cn.c.SetStreamThreshold(c.streamThreshold)
+	cn.c.SetLegacyTypes(c.legacyTypes)
+	cn.c.SetListColumns(c.listColumns)
+	cn.c.SetSliceExpansion(c.expandSlices)
+
 	return cn, nil
 }
 
+// CheckNamedValue converts parameters with the connection, which applies the
+// encoders of the registry and keeps stream parameters, so they can be
+// uploaded as server streams, and decimals, so they are sent exactly.
+// Unsupported types are reported here, before the statement is sent.
+func (cn *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := cn.c.CheckValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = value
+	return nil
+}
+
+// FeatureOptions returns the features negotiated with the server. It is
+// reachable through sql.Conn.Raw.
+func (cn *conn) FeatureOptions() connection.FeatureOption {
+	return cn.c.FeatureOptions()
+}
+
+// ServerInfo returns the description of the server of the connection, with
+// its parsed version and server job number. It is reachable through
+// sql.Conn.Raw.
+func (cn *conn) ServerInfo() (connection.ServerInfo, error) {
+	return cn.c.ServerInfo()
+}
+
 func (cn *conn) Begin() (driver.Tx, error) {
 	return cn.c.BeginTx(driver.TxOptions{})
 }
@@ -6,6 +6,8 @@ require github.com/magefile/mage v1.15.0
 
 require github.com/shopspring/decimal v1.4.0
 
+require golang.org/x/text v0.30.0
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
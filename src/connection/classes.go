@@ -8,7 +8,7 @@ func (c *Connection) ServerVersion() (result string, err error) {
 }
 
 func (c *Connection) ClassMethod(class, method string, result interface{}, args ...interface{}) (err error) {
-	msg := NewMessage(CLASSMETHOD_VALUE)
+	msg := c.newMessage(CLASSMETHOD_VALUE)
 	msg.Set(class)
 	msg.Set(method)
 	msg.Set(len(args))
@@ -16,11 +16,11 @@ func (c *Connection) ClassMethod(class, method string, result interface{}, args
 		msg.Set(arg)
 	}
 
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -31,7 +31,7 @@ func (c *Connection) ClassMethod(class, method string, result interface{}, args
 }
 
 func (c *Connection) ClassMethodVoid(class, method string, args ...interface{}) (err error) {
-	msg := NewMessage(CLASSMETHOD_VOID)
+	msg := c.newMessage(CLASSMETHOD_VOID)
 	msg.Set(class)
 	msg.Set(method)
 	msg.Set(len(args))
@@ -39,11 +39,11 @@ func (c *Connection) ClassMethodVoid(class, method string, args ...interface{})
 		msg.Set(arg)
 	}
 
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -51,7 +51,7 @@ func (c *Connection) ClassMethodVoid(class, method string, args ...interface{})
 }
 
 func (c *Connection) Method(obj iris.Oref, method string, result interface{}, args ...interface{}) (err error) {
-	msg := NewMessage(METHOD_VALUE)
+	msg := c.newMessage(METHOD_VALUE)
 	msg.Set(obj)
 	msg.Set(method)
 	msg.Set(len(args))
@@ -59,11 +59,11 @@ func (c *Connection) Method(obj iris.Oref, method string, result interface{}, ar
 		msg.Set(arg)
 	}
 
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -74,7 +74,7 @@ func (c *Connection) Method(obj iris.Oref, method string, result interface{}, ar
 }
 
 func (c *Connection) MethodVoid(obj, method string, args ...interface{}) (err error) {
-	msg := NewMessage(METHOD_VOID)
+	msg := c.newMessage(METHOD_VOID)
 	msg.Set(obj)
 	msg.Set(method)
 	msg.Set(len(args))
@@ -82,27 +82,27 @@ func (c *Connection) MethodVoid(obj, method string, args ...interface{}) (err er
 		msg.Set(arg)
 	}
 
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return
 	}
 	return
 }
 func (c *Connection) PropertyGet(obj iris.Oref, property string, result interface{}) (err error) {
-	msg := NewMessage(PROPERTY_GET)
+	msg := c.newMessage(PROPERTY_GET)
 	msg.Set(obj)
 	msg.Set(property)
 	// msg.Set(0)
 
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -0,0 +1,53 @@
+package connection
+
+import (
+	"time"
+
+	"github.com/caretdev/go-irisnative/src/horolog"
+)
+
+// codec holds the per-connection settings used to convert values between Go
+// and IRIS.
+type codec struct {
+	// location is the time zone IRIS timestamps are expressed in. When nil,
+	// timestamps are sent and parsed as UTC and %PosixTime values are
+	// returned in UTC.
+	location *time.Location
+	// naiveTime treats IRIS timestamps as wall-clock times: time.Time
+	// parameters are sent with their own wall clock, without conversion.
+	naiveTime bool
+	// registry holds the application conversions, if any.
+	registry *Registry
+	// legacyTypes returns int for the integer types narrower than BIGINT
+	// and float32 for FLOAT and REAL, as older versions of the driver did.
+	legacyTypes bool
+	// listColumns decodes string columns holding a $LIST into []interface{}.
+	listColumns bool
+}
+
+// serverLocation returns the location timestamps without a time zone are
+// parsed in.
+func (cd codec) serverLocation() *time.Location {
+	if cd.location == nil {
+		return time.UTC
+	}
+	return cd.location
+}
+
+// formatTime returns the ODBC timestamp sent for t. Dates and times of day
+// are sent as iris.Date and iris.TimeOfDay, whose values are ODBC strings.
+func (cd codec) formatTime(t time.Time) string {
+	if cd.naiveTime {
+		return t.Format(timeLayout)
+	}
+	return t.In(cd.serverLocation()).Format(timeLayout)
+}
+
+// fromPosix decodes a %PosixTime value.
+func (cd codec) fromPosix(value int64) time.Time {
+	if cd.naiveTime {
+		t := horolog.FromPosix(value, time.UTC)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), cd.serverLocation())
+	}
+	return horolog.FromPosix(value, cd.serverLocation())
+}
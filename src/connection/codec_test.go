@@ -0,0 +1,181 @@
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caretdev/go-irisnative/src/iris"
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestCodecLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	cd := codec{location: ny}
+
+	instant := time.Date(2025, time.July, 1, 16, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2025-07-01 12:00:00.000000000", cd.toODBC(instant))
+	value, err := cd.fromODBC(TYPE_TIMESTAMP, list.NewListItem("2025-07-01 12:00:00"))
+	assert.NoError(t, err)
+	assert.True(t, instant.Equal(value.(time.Time)))
+	assert.Equal(t, ny, value.(time.Time).Location())
+
+	// %PosixTime values are instants, returned in the connection location
+	value, err = cd.fromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636970432))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, time.September, 16, 6, 20, 30, 123456000, ny), value)
+}
+
+func TestCodecDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	cd := codec{location: ny}
+	roundTrip := func(cd codec, tm time.Time) time.Time {
+		value, err := cd.fromODBC(TYPE_TIMESTAMP, list.NewListItem(cd.toODBC(tm)))
+		assert.NoError(t, err)
+		return value.(time.Time)
+	}
+
+	// Spring forward: 2025-03-09 02:00 EST becomes 03:00 EDT
+	for _, tm := range []time.Time{
+		time.Date(2025, time.March, 9, 6, 59, 59, 0, time.UTC),
+		time.Date(2025, time.March, 9, 7, 0, 0, 0, time.UTC),
+	} {
+		assert.True(t, tm.Equal(roundTrip(cd, tm)), tm)
+	}
+	assert.Equal(t, "2025-03-09 03:00:00.000000000", cd.toODBC(time.Date(2025, time.March, 9, 7, 0, 0, 0, time.UTC)))
+
+	// Fall back: 01:30 happens twice and the second one cannot be told apart
+	first := time.Date(2025, time.November, 2, 5, 30, 0, 0, time.UTC)
+	second := time.Date(2025, time.November, 2, 6, 30, 0, 0, time.UTC)
+	assert.Equal(t, cd.toODBC(first), cd.toODBC(second))
+	assert.True(t, first.Equal(roundTrip(cd, first)))
+
+	// Naive wall-clock times round trip unchanged, whatever their zone
+	naive := codec{location: ny, naiveTime: true}
+	wall := time.Date(2025, time.March, 9, 2, 30, 0, 0, time.UTC)
+	assert.Equal(t, "2025-03-09 02:30:00.000000000", naive.toODBC(wall))
+	assert.Equal(t, "2025-11-02 01:30:00.000000000", naive.toODBC(second.In(ny)))
+	value, err := naive.fromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636970432))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, time.September, 16, 10, 20, 30, 123456000, ny), value)
+}
+
+func TestCodecDefaults(t *testing.T) {
+	// Without a location timestamps are sent and parsed as UTC
+	var cd codec
+	local := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	assert.Equal(t, "2025-07-01 10:00:00.000000000", cd.toODBC(local))
+	value, err := cd.fromODBC(TYPE_TIMESTAMP, list.NewListItem("2025-07-01 10:00:00"))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, time.July, 1, 10, 0, 0, 0, time.UTC), value)
+	value, err = cd.fromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636970432))
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, value.(time.Time).Location())
+}
+
+func TestCodecDateAndTime(t *testing.T) {
+	cd := codec{}
+	for _, tc := range []struct {
+		coltype SQLTYPE
+		value   interface{}
+		sent    string
+	}{
+		{DATE, "2024-06-09", "2024-06-09 00:00:00.000000000"},
+		{DATE, 67000, "2024-06-09 00:00:00.000000000"},
+		{TIME, "13:45:30", "0000-01-01 13:45:30.000000000"},
+		{TIME, 49530.25, "0000-01-01 13:45:30.250000000"},
+		{TYPE_DATE, 67000, "2024-06-09 00:00:00.000000000"},
+		{TYPE_TIME, 3600, "0000-01-01 01:00:00.000000000"},
+	} {
+		value, err := cd.fromODBC(tc.coltype, list.NewListItem(tc.value))
+		assert.NoError(t, err)
+		assert.IsType(t, time.Time{}, value)
+		// time.Time is always sent as a full timestamp
+		assert.Equal(t, tc.sent, cd.toODBC(value))
+	}
+
+	// Dates and times of day are sent through their own types
+	date := iris.DateOf(time.Date(2024, time.June, 9, 0, 0, 0, 0, time.UTC))
+	value, err := date.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-06-09", cd.toODBC(value))
+	clock := iris.TimeOfDayOf(time.Date(0, time.January, 1, 13, 45, 30, 0, time.UTC))
+	value, err = clock.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "13:45:30", cd.toODBC(value))
+}
+
+func TestCodecTimeLocation(t *testing.T) {
+	plus2 := time.FixedZone("UTC+2", 2*60*60)
+	minus5 := time.FixedZone("UTC-5", -5*60*60)
+	local := time.Date(2024, time.June, 9, 0, 30, 0, 0, plus2)
+
+	// Sent in the server location, UTC by default
+	assert.Equal(t, "2024-06-08 22:30:00.000000000", codec{}.toODBC(local))
+	assert.Equal(t, "2024-06-08 17:30:00.000000000", codec{location: minus5}.toODBC(local))
+	// or with its own wall clock when naive
+	assert.Equal(t, "2024-06-09 00:30:00.000000000", codec{location: minus5, naiveTime: true}.toODBC(local))
+}
+
+func TestLocaleEncoding(t *testing.T) {
+	enc := localeEncoding(true, "rusw")
+	li, err := enc.NewListItem("тест")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x0a, 0x02, 0x42, 0x04, 0x35, 0x04, 0x41, 0x04, 0x42, 0x04}, li.Dump())
+
+	enc = localeEncoding(false, "rusw")
+	li, err = enc.NewListItem("тест")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x06, 0x01, 0xf2, 0xe5, 0xf1, 0xf2}, li.Dump())
+	var offset uint
+	var v string
+	li = enc.GetListItem(li.Dump(), &offset)
+	assert.NoError(t, li.Get(&v))
+	assert.Equal(t, "тест", v)
+	_, err = enc.NewListItem("Größe")
+	assert.ErrorIs(t, err, list.ErrNotRepresentable)
+
+	enc = localeEncoding(false, "rus8")
+	li, err = enc.NewListItem("тест")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x06, 0x01, 0xe2, 0xd5, 0xe1, 0xe2}, li.Dump())
+
+	enc = localeEncoding(false, "deuw")
+	li, err = enc.NewListItem("Größe €")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x09, 0x01, 0x47, 0x72, 0xf6, 0xdf, 0x65, 0x20, 0x80}, li.Dump())
+	offset = 0
+	li = enc.GetListItem(li.Dump(), &offset)
+	assert.NoError(t, li.Get(&v))
+	assert.Equal(t, "Größe €", v)
+	_, err = enc.NewListItem("тест")
+	assert.ErrorIs(t, err, list.ErrNotRepresentable)
+
+	enc = localeEncoding(false, "deu8")
+	_, err = enc.NewListItem("€")
+	assert.ErrorIs(t, err, list.ErrNotRepresentable)
+
+	enc = localeEncoding(false, "xxx8")
+	li, err = enc.NewListItem("café")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x06, 0x01, 0x63, 0x61, 0x66, 0xe9}, li.Dump())
+
+	// The charset only applies to 8-bit servers
+	c := &Connection{unicode: true, encoding: localeEncoding(true, "rusw")}
+	c.SetCharset(list.NewCharset(charmap.Windows1251))
+	li, err = c.encoding.NewListItem("café")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x06, 0x01, 0x63, 0x61, 0x66, 0xe9}, li.Dump())
+	c = &Connection{encoding: localeEncoding(false, "xxx8")}
+	c.SetCharset(list.NewCharset(charmap.Windows1251))
+	li, err = c.encoding.NewListItem("тест")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x06, 0x01, 0xf2, 0xe5, 0xf1, 0xf2}, li.Dump())
+}
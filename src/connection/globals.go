@@ -1,19 +1,19 @@
 package connection
 
 func (c *Connection) GlobalIsDefined(global string, subs ...interface{}) (bool, bool) {
-	msg := NewMessage(GLOBAL_DATA)
+	msg := c.newMessage(GLOBAL_DATA)
 	msg.Set(global)
 	msg.Set(len(subs))
 	for _, sub := range subs {
 		msg.Set(sub)
 	}
 	msg.Set(0)
-	_, err := c.conn.Write(msg.Dump(c.count()))
+	err := c.write(msg)
 	if err != nil {
 		return false, false
 	}
 
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return false, false
 	}
@@ -24,7 +24,7 @@ func (c *Connection) GlobalIsDefined(global string, subs ...interface{}) (bool,
 }
 
 func (c *Connection) GlobalSet(global string, value interface{}, subs ...interface{}) (err error) {
-	msg := NewMessage(GLOBAL_SET)
+	msg := c.newMessage(GLOBAL_SET)
 	msg.Set(global)
 	msg.Set(len(subs))
 	for _, sub := range subs {
@@ -32,12 +32,12 @@ func (c *Connection) GlobalSet(global string, value interface{}, subs ...interfa
 	}
 	msg.Set(value)
 
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
 
-	_, err = ReadMessage(c.conn)
+	_, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -46,19 +46,19 @@ func (c *Connection) GlobalSet(global string, value interface{}, subs ...interfa
 }
 
 func (c *Connection) GlobalKill(global string, subs ...interface{}) (err error) {
-	msg := NewMessage(GLOBAL_KILL)
+	msg := c.newMessage(GLOBAL_KILL)
 	msg.Set(global)
 	msg.Set(len(subs))
 	for _, sub := range subs {
 		msg.Set(sub)
 	}
 
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
 
-	_, err = ReadMessage(c.conn)
+	_, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -67,19 +67,19 @@ func (c *Connection) GlobalKill(global string, subs ...interface{}) (err error)
 }
 
 func (c *Connection) GlobalGet(global string, result interface{}, subs ...interface{}) (err error) {
-	msg := NewMessage(GLOBAL_GET)
+	msg := c.newMessage(GLOBAL_GET)
 	msg.Set(global)
 	msg.Set(len(subs))
 	for _, sub := range subs {
 		msg.Set(sub)
 	}
 
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
 
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -89,7 +89,7 @@ func (c *Connection) GlobalGet(global string, result interface{}, subs ...interf
 }
 
 func (c *Connection) GlobalNext(global string, ind *string, subs ...interface{}) (hasNext bool, err error) {
-	msg := NewMessage(GLOBAL_ORDER)
+	msg := c.newMessage(GLOBAL_ORDER)
 	msg.Set(global)
 	msg.Set(len(subs) + 1)
 	for _, sub := range subs {
@@ -98,11 +98,11 @@ func (c *Connection) GlobalNext(global string, ind *string, subs ...interface{})
 	msg.Set(*ind)
 	msg.Set(3)
 
-	if _, err = c.conn.Write(msg.Dump(c.count())); err != nil {
+	if err = c.write(msg); err != nil {
 		return
 	}
 
-	if msg, err = ReadMessage(c.conn); err != nil {
+	if msg, err = c.readMessage(); err != nil {
 		return
 	}
 
@@ -115,7 +115,7 @@ func (c *Connection) GlobalNext(global string, ind *string, subs ...interface{})
 }
 
 func (c *Connection) GlobalPrev(global string, ind *string, subs ...interface{}) (hasNext bool, err error) {
-	msg := NewMessage(GLOBAL_ORDER)
+	msg := c.newMessage(GLOBAL_ORDER)
 	msg.Set(global)
 	msg.Set(len(subs) + 1)
 	for _, sub := range subs {
@@ -124,11 +124,11 @@ func (c *Connection) GlobalPrev(global string, ind *string, subs ...interface{})
 	msg.Set(*ind)
 	msg.Set(7)
 
-	if _, err = c.conn.Write(msg.Dump(c.count())); err != nil {
+	if err = c.write(msg); err != nil {
 		return
 	}
 
-	if msg, err = ReadMessage(c.conn); err != nil {
+	if msg, err = c.readMessage(); err != nil {
 		return
 	}
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"unicode/utf8"
 
 	"github.com/caretdev/go-irisnative/src/list"
 )
@@ -12,6 +13,11 @@ type Message struct {
 	header MessageHeader
 	data   []byte
 	offset uint
+	// enc encodes and decodes the strings of the message; nil means
+	// list.DefaultEncoding
+	enc *list.Encoding
+	// err is the first error of Set, reported when the message is sent
+	err error
 }
 
 func NewMessage(messageType MessageType) Message {
@@ -19,6 +25,8 @@ func NewMessage(messageType MessageType) Message {
 		NewMessageHeader(messageType),
 		[]byte{},
 		0,
+		nil,
+		nil,
 	}
 }
 
@@ -52,7 +60,7 @@ func ReadMessage(conn *net.TCPConn) (msg Message, err error) {
 		}
 	}
 
-	msg = Message{msgHeader, data, 0}
+	msg = Message{msgHeader, data, 0, nil, nil}
 
 	return
 }
@@ -93,30 +101,38 @@ func (m *Message) GetRaw(value interface{}) error {
 }
 
 func (m *Message) Set(value interface{}) error {
-	listItem := list.NewListItem(value)
+	listItem, err := m.enc.NewListItem(value)
+	if err != nil {
+		if m.err == nil {
+			m.err = err
+		}
+		return err
+	}
 	m.AddRaw(listItem.Dump())
 	return nil
 }
 
 func (m *Message) SetSQLText(sqlText string) error {
-	len := len(sqlText)
-	if len == 0 {
+	if len(sqlText) == 0 {
 		m.Set(sqlText)
 		return nil
 	}
 	const chunksize = 31904
-	chunks := len / chunksize
-	if len%chunksize != 0 {
-		chunks += 1
-	}
-	m.Set(chunks)
-	for i := 0; i < chunks; i++ {
-		begin := i * chunksize
-		end := (i + 1) * chunksize
-		if end > len {
-			end = len
+	// Split on character boundaries, so that no chunk ends in the middle of
+	// a multi-byte character
+	var chunks []string
+	for len(sqlText) > chunksize {
+		end := chunksize
+		for end > 0 && !utf8.RuneStart(sqlText[end]) {
+			end--
 		}
-		m.Set(sqlText[begin:end])
+		chunks = append(chunks, sqlText[:end])
+		sqlText = sqlText[end:]
+	}
+	chunks = append(chunks, sqlText)
+	m.Set(len(chunks))
+	for _, chunk := range chunks {
+		m.Set(chunk)
 	}
 	return nil
 }
@@ -126,7 +142,7 @@ func (m *Message) GetStatus() uint16 {
 }
 
 func (m *Message) Get(value interface{}) error {
-	listItem := list.GetListItem(m.data, &m.offset)
+	listItem := m.enc.GetListItem(m.data, &m.offset)
 	listItem.Get(value)
 	return nil
 }
@@ -142,7 +158,7 @@ func (v *AnyType) Int() int {
 }
 
 func (m *Message) GetAny() AnyType {
-	listItem := list.GetListItem(m.data, &m.offset)
+	listItem := m.enc.GetListItem(m.data, &m.offset)
 	return AnyType{listItem}
 }
 
@@ -3,23 +3,43 @@ package connection
 import (
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caretdev/go-irisnative/src/list"
 )
 
 const VERSION_PROTOCOL uint16 = 69
 
 type Connection struct {
-	conn            *net.TCPConn
-	messageCount    uint32
-	statement       uint32
-	unicode         bool
-	locale          string
-	version         uint16
-	info            string
-	featureOptions  uint
+	conn           *net.TCPConn
+	messageCount   uint32
+	statement      uint32
+	unicode        bool
+	locale         string
+	version        uint16
+	info           string
+	featureOptions FeatureOption
+	// serverInfo holds the settings of the server reported by CONNECT
+	serverInfo      ServerInfo
 	tx              bool
 	maxRowsPerFetch int
 	queryTimeout    int
+	streamLOBs      bool
+	// streamThreshold is the size above which string and []byte parameters
+	// are uploaded as streams; 0 disables it
+	streamThreshold int
+	// noSliceExpansion disables the expansion of slices bound to IN (?)
+	noSliceExpansion bool
+	// fetchKeys fetches the keys generated by INSERT statements
+	fetchKeys bool
+	codec     codec
+	// encoding is the string encoding negotiated in the handshake
+	encoding *list.Encoding
 }
 
 var (
@@ -27,9 +47,71 @@ var (
 	errBeginTx                = errors.New("could not begin transaction")
 	errMultipleTx             = errors.New("multiple transactions")
 	errReadOnlyTxNotSupported = errors.New("read-only transactions are not supported")
+	errFastInsertNotSupported = errors.New("feature option fast_insert is not supported")
 )
 
+// ConnectOptions holds the settings sent to the server when connecting.
+type ConnectOptions struct {
+	// FeatureOptions are the features requested from the server, which
+	// grants a subset of them. When nil, DefaultFeatureOptions are
+	// requested; point to OptionNone to request none.
+	FeatureOptions *FeatureOption
+	// ApplicationName identifies the client application in the server
+	// process and audit records; it defaults to DefaultApplicationName.
+	ApplicationName string
+	// MachineName is the client host name; it defaults to os.Hostname.
+	MachineName string
+	// OSUser is the client operating system user; it defaults to the
+	// current user.
+	OSUser string
+	// EventClass is the class of the server events of the connection,
+	// none by default.
+	EventClass string
+}
+
+// featureOptions returns the features requested from the server, with the
+// default applied.
+func (o ConnectOptions) featureOptions() FeatureOption {
+	if o.FeatureOptions == nil {
+		return DefaultFeatureOptions
+	}
+	return *o.FeatureOptions
+}
+
+// DefaultApplicationName is the application name sent when none is set.
+const DefaultApplicationName = "libirisnative"
+
+// identity returns the OS user, machine name and application name sent to
+// the server, with the defaults applied.
+func (o ConnectOptions) identity() (osUser, machineName, applicationName string) {
+	osUser, machineName, applicationName = o.OSUser, o.MachineName, o.ApplicationName
+	if osUser == "" {
+		var err error
+		if osUser, err = systemUser(); err != nil {
+			osUser = "go"
+		}
+	}
+	if machineName == "" {
+		var err error
+		if machineName, err = os.Hostname(); err != nil || machineName == "" {
+			machineName = "go-machine"
+		}
+	}
+	if applicationName == "" {
+		applicationName = DefaultApplicationName
+	}
+	return
+}
+
 func Connect(addr string, namespace, login, password string) (connection Connection, err error) {
+	return ConnectWithOptions(addr, namespace, login, password, ConnectOptions{})
+}
+
+// ConnectWithOptions connects like Connect, with the settings of options.
+func ConnectWithOptions(addr string, namespace, login, password string, options ConnectOptions) (connection Connection, err error) {
+	if options.featureOptions()&OptionFastInsert != 0 {
+		return connection, errFastInsertNotSupported
+	}
 
 	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
 	if err != nil {
@@ -45,13 +127,14 @@ func Connect(addr string, namespace, login, password string) (connection Connect
 		conn:            conn,
 		maxRowsPerFetch: DefaultMaxRowsPerFetch,
 		queryTimeout:    DefaultQueryTimeout,
+		streamThreshold: DefaultStreamThreshold,
 	}
 
 	if err = connection.handshake(); err != nil {
 		return
 	}
 
-	if err = connection.connect(namespace, login, password); err != nil {
+	if err = connection.connect(namespace, login, password, options); err != nil {
 		return
 	}
 
@@ -72,9 +155,68 @@ func (c *Connection) SetQueryTimeout(timeout int) {
 	}
 }
 
+// SetStreamLOBs controls whether stream columns larger than one chunk are
+// returned as lazily read *Stream values instead of being read completely.
+func (c *Connection) SetStreamLOBs(stream bool) {
+	c.streamLOBs = stream
+}
+
+// SetStreamThreshold makes string and []byte parameters larger than bytes be
+// uploaded as server streams and bound as LONGVARCHAR or LONGVARBINARY, which
+// only suits parameters stored in stream columns. It defaults to
+// DefaultStreamThreshold and 0 disables it; io.Reader parameters are always
+// uploaded as streams.
+func (c *Connection) SetStreamThreshold(bytes int) {
+	if bytes >= 0 {
+		c.streamThreshold = bytes
+	}
+}
+
+// SetGeneratedKeys makes INSERT statements fetch the keys they generate,
+// which LastInsertId and GeneratedKeys return. It costs a round trip per
+// executed batch and is disabled by default.
+func (c *Connection) SetGeneratedKeys(fetch bool) {
+	c.fetchKeys = fetch
+}
+
+// SetLegacyTypes makes TINYINT, SMALLINT and INTEGER columns return int and
+// FLOAT and REAL columns float32, as older versions of the driver did,
+// instead of int64 and float64.
+func (c *Connection) SetLegacyTypes(legacy bool) {
+	c.codec.legacyTypes = legacy
+}
+
+// SetListColumns makes string columns whose value is a well-formed $LIST, as
+// stored in %List properties or built with $LISTBUILD, return []interface{}
+// of the item values instead of a string. The column metadata does not tell
+// lists from text, so text that happens to be a valid $LIST is decoded too;
+// it is disabled by default, and list.List scans the strings either way.
+func (c *Connection) SetListColumns(decode bool) {
+	c.codec.listColumns = decode
+}
+
+// SetSliceExpansion controls whether slice parameters bound to IN (?) are
+// expanded into one parameter per element. It is enabled by default.
+func (c *Connection) SetSliceExpansion(expand bool) {
+	c.noSliceExpansion = !expand
+}
+
+// SetLocation sets the time zone IRIS timestamps are expressed in. It is used
+// for time.Time parameters as well as for decoded values.
+func (c *Connection) SetLocation(loc *time.Location) {
+	c.codec.location = loc
+}
+
+// SetNaiveTime treats IRIS timestamps as wall-clock times without a time
+// zone: parameters are sent with their own wall clock and values are returned
+// with the same wall clock in the connection location.
+func (c *Connection) SetNaiveTime(naive bool) {
+	c.codec.naiveTime = naive
+}
+
 func (c *Connection) Disconnect() {
-	msg := NewMessage(DISCONNECT)
-	c.conn.Write(msg.Dump(c.count()))
+	msg := c.newMessage(DISCONNECT)
+	c.write(msg)
 }
 
 func (c *Connection) count() uint32 {
@@ -83,6 +225,31 @@ func (c *Connection) count() uint32 {
 	return count
 }
 
+// newMessage returns a message of the given type, using the string encoding
+// of the connection.
+func (c *Connection) newMessage(messageType MessageType) Message {
+	msg := NewMessage(messageType)
+	msg.enc = c.encoding
+	return msg
+}
+
+// write sends msg, unless one of its values could not be encoded.
+func (c *Connection) write(msg Message) error {
+	if msg.err != nil {
+		return msg.err
+	}
+	_, err := c.conn.Write(msg.Dump(c.count()))
+	return err
+}
+
+// readMessage reads the next message, using the string encoding of the
+// connection.
+func (c *Connection) readMessage() (Message, error) {
+	msg, err := ReadMessage(c.conn)
+	msg.enc = c.encoding
+	return msg, err
+}
+
 func (c *Connection) statementId() uint32 {
 	statement := c.statement
 	c.statement += 1
@@ -90,15 +257,15 @@ func (c *Connection) statementId() uint32 {
 }
 
 func (c *Connection) handshake() (err error) {
-	var message = NewMessage(HANDSHAKE)
+	var message = c.newMessage(HANDSHAKE)
 	message.AddRaw(VERSION_PROTOCOL)
 
-	_, err = c.conn.Write(message.Dump(c.count()))
+	err = c.write(message)
 	if err != nil {
 		return
 	}
 
-	msg, err := ReadMessage(c.conn)
+	msg, err := c.readMessage()
 	if err != nil {
 		return
 	}
@@ -114,9 +281,45 @@ func (c *Connection) handshake() (err error) {
 	var locale string
 	msg.Get(&locale)
 	c.locale = locale
+	c.encoding = localeEncoding(c.unicode, c.locale)
 	return
 }
 
+// localeEncoding returns the string encoding for the unicode flag and locale
+// reported by the server. Unicode servers store 8-bit strings as Latin-1;
+// 8-bit servers only accept strings of the character set of their locale.
+func localeEncoding(unicode bool, locale string) *list.Encoding {
+	if unicode {
+		return &list.Encoding{Unicode: true}
+	}
+	charset, ok := list.LocaleCharset(locale)
+	if !ok {
+		charset = list.Latin1
+	}
+	return &list.Encoding{Charset: charset}
+}
+
+// SetCharset overrides the character set of 8-bit strings derived from the
+// server locale. It is meant for 8-bit servers with a locale the driver does
+// not know; Unicode servers always use Latin-1 for 8-bit strings, so it has
+// no effect on them.
+func (c *Connection) SetCharset(charset list.Charset) {
+	if c.unicode {
+		return
+	}
+	c.encoding = &list.Encoding{Charset: charset}
+}
+
+// Locale returns the locale reported by the server, such as "enuw".
+func (c *Connection) Locale() string {
+	return c.locale
+}
+
+// Unicode reports whether the server supports Unicode strings.
+func (c *Connection) Unicode() bool {
+	return c.unicode
+}
+
 func encode(value string) []byte {
 	in := []byte(value)
 	length := len(in)
@@ -141,45 +344,116 @@ const (
 	OptionRedirectOutput      FeatureOption = 32
 )
 
+// DefaultFeatureOptions are the features requested by Connect. Fast insert
+// is not supported: the driver does not implement its insert protocol, so
+// ParseFeatureOptions and ConnectWithOptions reject it.
+const DefaultFeatureOptions = OptionFastSelect | OptionDurableTransactions | OptionRedirectOutput
+
+// featureOptionNames are the names of the feature options, as accepted by
+// ParseFeatureOptions.
+var featureOptionNames = []struct {
+	option FeatureOption
+	name   string
+}{
+	{OptionFastSelect, "fast_select"},
+	{OptionFastInsert, "fast_insert"},
+	{OptionDurableTransactions, "durable_transactions"},
+	{OptionNotNullable, "not_nullable"},
+	{OptionRedirectOutput, "redirect_output"},
+}
+
+// ParseFeatureOptions parses feature options given as a number or as comma
+// separated names, such as "durable_transactions,redirect_output". "none"
+// and the empty string stand for OptionNone. OptionFastInsert is rejected.
+func ParseFeatureOptions(s string) (FeatureOption, error) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		if FeatureOption(n)&OptionFastInsert != 0 {
+			return OptionNone, errFastInsertNotSupported
+		}
+		return FeatureOption(n), nil
+	}
+	options := OptionNone
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "none" {
+			continue
+		}
+		option, ok := featureOptionByName(name)
+		if !ok {
+			return OptionNone, fmt.Errorf("unknown feature option %q", name)
+		}
+		if option == OptionFastInsert {
+			return OptionNone, errFastInsertNotSupported
+		}
+		options |= option
+	}
+	return options, nil
+}
+
+func featureOptionByName(name string) (FeatureOption, bool) {
+	for _, n := range featureOptionNames {
+		if n.name == name {
+			return n.option, true
+		}
+	}
+	return OptionNone, false
+}
+
+// String returns the names of the options separated by commas, "none" for
+// OptionNone.
+func (o FeatureOption) String() string {
+	var names []string
+	for _, n := range featureOptionNames {
+		if o&n.option != 0 {
+			names = append(names, n.name)
+			o &^= n.option
+		}
+	}
+	if o != 0 {
+		names = append(names, strconv.FormatUint(uint64(o), 10))
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// FeatureOptions returns the features negotiated with the server, those
+// requested when connecting that the server granted.
+func (c *Connection) FeatureOptions() FeatureOption {
+	return c.featureOptions
+}
+
 func (c *Connection) IsOptionFastInsert() bool {
-	return c.featureOptions&uint(OptionFastInsert) == uint(OptionFastInsert)
+	return c.featureOptions&OptionFastInsert == OptionFastInsert
 }
 
 func (c *Connection) IsOptionFastSelect() bool {
-	return c.featureOptions&uint(OptionFastSelect) == uint(OptionFastSelect)
+	return c.featureOptions&OptionFastSelect == OptionFastSelect
 }
 
-func (c *Connection) connect(namespace, login, password string) (err error) {
-	msg := NewMessage(CONNECT)
+func (c *Connection) connect(namespace, login, password string, options ConnectOptions) (err error) {
+	msg := c.newMessage(CONNECT)
 	msg.Set(namespace)
 	msg.Set(encode(login))
 	msg.Set(encode(password))
-	var user = "go"
-	if user, err = systemUser(); err != nil {
-		user = "go"
-	}
-	msg.Set(user)            // machine user name
-	msg.Set("go-machine")    // machine name
-	msg.Set("libirisnative") // application name
-	msg.Set("")              // ?
-	msg.Set("go")            // SharedMemoryFlag?
-	msg.Set("")              // EventClass
-	msg.Set(1)               // AutoCommit ? 1 : 2
-	msg.Set(0)               // IsolationLevel
-	var featureOptions = OptionNone
-	featureOptions += OptionFastSelect
-	// Tricky to make it fully working yet
-	// featureOptions += OptionFastInsert
-	featureOptions += OptionDurableTransactions
-	featureOptions += OptionRedirectOutput
-	msg.Set(int(featureOptions)) // FeatureOption
-
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	user, machine, application := options.identity()
+	msg.Set(user)                          // machine user name
+	msg.Set(machine)                       // machine name
+	msg.Set(application)                   // application name
+	msg.Set("")                            // ?
+	msg.Set("go")                          // SharedMemoryFlag?
+	msg.Set(options.EventClass)            // EventClass
+	msg.Set(1)                             // AutoCommit ? 1 : 2
+	msg.Set(0)                             // IsolationLevel
+	msg.Set(int(options.featureOptions())) // FeatureOption
+
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
 
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -194,20 +468,16 @@ func (c *Connection) connect(namespace, login, password string) (err error) {
 	msg.Get(&info)
 	c.info = info
 	var (
-		delimited_ids        bool
 		ignored              int
-		isolationLevel       int
-		serverJobNumber      string
-		sqlEmptyString       int
 		serverFeatureOptions uint
 	)
-	msg.Get(&delimited_ids)
+	msg.Get(&c.serverInfo.DelimitedIDs)
 	msg.Get(&ignored)
-	msg.Get(&isolationLevel)
-	msg.Get(&serverJobNumber)
-	msg.Get(&sqlEmptyString)
+	msg.Get(&c.serverInfo.IsolationLevel)
+	msg.Get(&c.serverInfo.JobNumber)
+	msg.Get(&c.serverInfo.SQLEmptyString)
 	msg.Get(&serverFeatureOptions)
-	c.featureOptions = serverFeatureOptions
+	c.featureOptions = FeatureOption(serverFeatureOptions)
 	return
 }
 
@@ -220,12 +490,12 @@ func systemUser() (string, error) {
 }
 
 func (c *Connection) Commit() (err error) {
-	msg := NewMessage(COMMIT)
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	msg := c.newMessage(COMMIT)
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
-	_, err = ReadMessage(c.conn)
+	_, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -233,12 +503,12 @@ func (c *Connection) Commit() (err error) {
 }
 
 func (c *Connection) Rollback() (err error) {
-	msg := NewMessage(ROLLBACK)
-	_, err = c.conn.Write(msg.Dump(c.count()))
+	msg := c.newMessage(ROLLBACK)
+	err = c.write(msg)
 	if err != nil {
 		return
 	}
-	_, err = ReadMessage(c.conn)
+	_, err = c.readMessage()
 	if err != nil {
 		return
 	}
@@ -0,0 +1,236 @@
+package connection
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/caretdev/go-irisnative/src/iris"
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/shopspring/decimal"
+)
+
+// ErrUnsupportedType is returned for parameters of a type the driver cannot
+// send and no encoder is registered for.
+var ErrUnsupportedType = errors.New("unsupported parameter type")
+
+// Encoder converts a parameter of a registered Go type into a value the
+// driver sends: nil, a string, []byte, bool, an integer, a float, time.Time,
+// decimal.Decimal, a Vector, an io.Reader or a driver.Valuer.
+type Encoder func(value interface{}) (interface{}, error)
+
+// Decoder converts a column of a registered SQL type, as sent by the server,
+// into the value returned to the application.
+type Decoder func(item list.ListItem) (interface{}, error)
+
+// Registry holds application conversions for parameters, by Go type, and for
+// columns, by SQL type. Conversions registered for a type replace the
+// built-in ones. A Registry is safe for concurrent use and can be shared by
+// connections.
+type Registry struct {
+	mu       sync.RWMutex
+	encoders map[reflect.Type]Encoder
+	decoders map[SQLTYPE]Decoder
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		encoders: make(map[reflect.Type]Encoder),
+		decoders: make(map[SQLTYPE]Decoder),
+	}
+}
+
+// RegisterEncoder registers the encoder for parameters of the type of sample.
+func (r *Registry) RegisterEncoder(sample interface{}, encoder Encoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.encoders[reflect.TypeOf(sample)] = encoder
+}
+
+// RegisterDecoder registers the decoder for columns of the SQL type coltype.
+func (r *Registry) RegisterDecoder(coltype SQLTYPE, decoder Decoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoders[coltype] = decoder
+}
+
+func (r *Registry) encoder(value interface{}) (Encoder, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	encoder, ok := r.encoders[reflect.TypeOf(value)]
+	return encoder, ok
+}
+
+func (r *Registry) decoder(coltype SQLTYPE) (Decoder, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	decoder, ok := r.decoders[coltype]
+	return decoder, ok
+}
+
+// SetRegistry sets the registry of application conversions; nil disables
+// them.
+func (c *Connection) SetRegistry(registry *Registry) {
+	c.codec.registry = registry
+}
+
+// CheckValue converts a parameter into one of the types the driver sends,
+// applying registered encoders and driver.Valuer, dereferencing pointers and
+// reducing named types to their underlying kind. Unsupported types fail with
+// an error wrapping ErrUnsupportedType. Slices are kept for expansion into
+// IN (?) lists, unless that is disabled; their elements are checked when the
+// statement is formatted.
+func (c *Connection) CheckValue(value interface{}) (interface{}, error) {
+	if _, ok := c.codec.registry.encoder(value); !ok && !c.noSliceExpansion {
+		if _, ok := expandSlice(value); ok {
+			return value, nil
+		}
+	}
+	return c.codec.checkValue(value)
+}
+
+// checkedValue is a parameter already converted by CheckValue, which
+// checkValues passes on as it is, so that encoders and Valuers run once.
+type checkedValue struct {
+	value interface{}
+}
+
+// checked marks a parameter converted by CheckValue. Slices CheckValue keeps
+// for IN (?) lists are left unmarked: their elements are converted when the
+// statement is formatted.
+func (c *Connection) checked(value interface{}) interface{} {
+	if _, ok := c.codec.registry.encoder(value); !ok && !c.noSliceExpansion {
+		if _, ok := expandSlice(value); ok {
+			return value
+		}
+	}
+	return checkedValue{value}
+}
+
+// checkValues converts the parameters of a statement with checkValue. The
+// args slice is not modified.
+func (cd codec) checkValues(args []interface{}) ([]interface{}, error) {
+	checked := make([]interface{}, len(args))
+	for i, arg := range args {
+		if v, ok := arg.(checkedValue); ok {
+			checked[i] = v.value
+			continue
+		}
+		value, err := cd.checkValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+		}
+		checked[i] = value
+	}
+	return checked, nil
+}
+
+// maxValuerDepth limits the number of conversions applied to a parameter, so
+// that an encoder or Valuer returning its own type does not loop forever.
+const maxValuerDepth = 8
+
+func (cd codec) checkValue(value interface{}) (interface{}, error) {
+	original := value
+	for range maxValuerDepth {
+		if nv, ok := value.(driver.NamedValue); ok {
+			value = nv.Value
+		}
+		if encoder, ok := cd.registry.encoder(value); ok {
+			var err error
+			if value, err = encoder(value); err != nil {
+				return nil, err
+			}
+		}
+		if u, ok := asUUID(value); ok {
+			// Sent in canonical form, also for UUID types whose Valuer
+			// uses lowercase digits
+			return u.String(), nil
+		}
+		switch v := value.(type) {
+		case nil, string, []byte, bool,
+			int, int8, int16, int32, int64,
+			uint, uint8, uint16, uint32, uint64,
+			float32, float64, time.Time, decimal.Decimal, *big.Int,
+			Vector, []int32, []int64, []float32, []float64,
+			io.Reader, streamHandle:
+			return value, nil
+		case driver.Valuer:
+			rv := reflect.ValueOf(v)
+			if rv.Kind() == reflect.Pointer && rv.IsNil() {
+				return nil, nil
+			}
+			next, err := v.Value()
+			if err != nil {
+				return nil, err
+			}
+			if next != nil && reflect.TypeOf(next) == rv.Type() {
+				return nil, fmt.Errorf("%w %T: its Value returns a %T again", ErrUnsupportedType, original, next)
+			}
+			value = next
+			continue
+		}
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Pointer:
+			if rv.IsNil() {
+				return nil, nil
+			}
+			value = rv.Elem().Interface()
+			continue
+		case reflect.String:
+			return rv.String(), nil
+		case reflect.Bool:
+			return rv.Bool(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return rv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return rv.Uint(), nil
+		case reflect.Float32, reflect.Float64:
+			return rv.Float(), nil
+		case reflect.Slice:
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				return rv.Bytes(), nil
+			}
+		case reflect.Array:
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				b := make([]byte, rv.Len())
+				reflect.Copy(reflect.ValueOf(b), rv)
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("%w %T", ErrUnsupportedType, value)
+	}
+	return nil, fmt.Errorf("%w %T: still a %T after %d conversions", ErrUnsupportedType, original, value, maxValuerDepth)
+}
+
+// asUUID returns the UUID of iris.UUID values and of the UUID types of other
+// packages: 16-byte arrays that are also a fmt.Stringer or a driver.Valuer,
+// such as github.com/google/uuid.UUID. Other byte arrays are binary data.
+func asUUID(value interface{}) (iris.UUID, bool) {
+	switch v := value.(type) {
+	case iris.UUID:
+		return v, true
+	case fmt.Stringer, driver.Valuer:
+	default:
+		return iris.UUID{}, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Array || rv.Len() != 16 || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return iris.UUID{}, false
+	}
+	var u iris.UUID
+	reflect.Copy(reflect.ValueOf(u[:]), rv)
+	return u, true
+}
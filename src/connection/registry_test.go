@@ -0,0 +1,166 @@
+package connection
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type point struct{ X, Y int }
+
+type status int
+
+type celsius float64
+
+func (c celsius) Value() (driver.Value, error) {
+	return decimal.NewFromFloat(float64(c)).StringFixed(1), nil
+}
+
+type loop struct{}
+
+func (l loop) Value() (driver.Value, error) {
+	return l, nil
+}
+
+// ping and pong are Valuers returning each other.
+type ping struct{}
+
+type pong struct{}
+
+func (ping) Value() (driver.Value, error) { return pong{}, nil }
+
+func (pong) Value() (driver.Value, error) { return ping{}, nil }
+
+func TestCheckValue(t *testing.T) {
+	s := "text"
+	var nilString *string
+	var nilCelsius *celsius
+	reader := strings.NewReader("stream")
+	for _, tc := range []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{nil, nil},
+		{"a", "a"},
+		{42, 42},
+		{uint64(1 << 63), uint64(1 << 63)},
+		{[]byte{1}, []byte{1}},
+		{decimal.RequireFromString("1.5"), decimal.RequireFromString("1.5")},
+		{big.NewInt(7), big.NewInt(7)},
+		{reader, reader},
+		{&s, "text"},
+		{nilString, nil},
+		{status(3), int64(3)},
+		{celsius(21.5), "21.5"},
+		{nilCelsius, nil},
+		{sql.NullString{String: "x", Valid: true}, "x"},
+		{sql.NullInt64{}, nil},
+		{driver.NamedValue{Ordinal: 1, Value: "named"}, "named"},
+	} {
+		value, err := codec{}.checkValue(tc.value)
+		assert.NoError(t, err, "%T", tc.value)
+		assert.Equal(t, tc.expected, value, "%T", tc.value)
+	}
+
+	for _, value := range []interface{}{point{1, 2}, []string{"a"}, map[string]int{}, loop{}} {
+		_, err := codec{}.checkValue(value)
+		assert.ErrorIs(t, err, ErrUnsupportedType, "%T", value)
+	}
+
+	// Conversions that cannot end are reported as such
+	_, err := codec{}.checkValue(loop{})
+	assert.EqualError(t, err, "unsupported parameter type connection.loop: its Value returns a connection.loop again")
+	_, err = codec{}.checkValue(ping{})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.EqualError(t, err, "unsupported parameter type connection.ping: still a connection.ping after 8 conversions")
+
+	_, err = codec{}.checkValues([]interface{}{1, point{}})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.Contains(t, err.Error(), "parameter 2")
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterEncoder(point{}, func(value interface{}) (interface{}, error) {
+		p := value.(point)
+		return list.NewList(p.X, p.Y)
+	})
+	failure := errors.New("negative status")
+	registry.RegisterEncoder(status(0), func(value interface{}) (interface{}, error) {
+		if value.(status) < 0 {
+			return nil, failure
+		}
+		return driver.Valuer(celsius(value.(status))), nil
+	})
+	registry.RegisterDecoder(VARCHAR, func(li list.ListItem) (interface{}, error) {
+		var s string
+		err := li.Get(&s)
+		return strings.ToUpper(s), err
+	})
+	cd := codec{registry: registry}
+
+	value, err := cd.checkValue(point{1, 2})
+	assert.NoError(t, err)
+	expected, _ := list.NewList(1, 2)
+	assert.Equal(t, []byte(expected), value)
+
+	value, err = cd.checkValue(status(20))
+	assert.NoError(t, err)
+	assert.Equal(t, "20.0", value)
+	_, err = cd.checkValue(status(-1))
+	assert.ErrorIs(t, err, failure)
+
+	value, err = cd.fromODBC(VARCHAR, list.NewListItem("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "ABC", value)
+	value, err = cd.fromODBC(VARCHAR, list.NewListItem(nil))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = cd.fromODBC(CHAR, list.NewListItem("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", value)
+
+	// Unsupported values fail the message instead of being sent as text
+	msg := NewMessage(DIRECT_QUERY)
+	cd.writeParameters(&msg, point{})
+	assert.Error(t, msg.err)
+}
+
+func TestCheckedParameters(t *testing.T) {
+	c, requests := testConnection(t, func(req Message) []Message {
+		return reply(0, 0, 0, 1)
+	})
+	registry := NewRegistry()
+	registry.RegisterEncoder("", func(value interface{}) (interface{}, error) {
+		return value.(string) + "!", nil
+	})
+	c.SetRegistry(registry)
+
+	// database/sql converts the arguments with CheckValue before Stmt.Exec
+	value, err := c.CheckValue("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a!", value)
+	in, err := c.CheckValue([]int{1, 2})
+	require.NoError(t, err)
+	st, err := c.Prepare("UPDATE Sample.Person SET Name = ? WHERE ID IN (?)")
+	require.NoError(t, err)
+	_, err = st.Exec([]driver.Value{value, in})
+	require.NoError(t, err)
+	items := requestItems(requests()[0])
+	assert.Equal(t, []interface{}{"a!", int64(1), int64(2)}, items[len(items)-3:])
+
+	// The native API converts them itself
+	_, err = c.DirectUpdate("UPDATE Sample.Person SET Name = ?", "b")
+	require.NoError(t, err)
+	items = requestItems(requests()[1])
+	assert.Contains(t, items, "b!")
+	assert.NotContains(t, items, "b!!")
+}
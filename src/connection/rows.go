@@ -5,31 +5,48 @@ import (
 	"errors"
 	"reflect"
 	"strings"
-	"time"
 )
 
 var (
-	errNoRowsAffected = errors.New("no RowsAffected available after the empty statement")
-	errNoLastInsertID = errors.New("no LastInsertId available after the empty statement")
+	errNoRowsAffected     = errors.New("no RowsAffected available after the empty statement")
+	errNoLastInsertID     = errors.New("no LastInsertId available after the empty statement")
+	errNoGeneratedKeys    = errors.New("no generated keys available for the statement")
+	errKeysNotFetched     = errors.New("generated keys are not fetched; enable them with the generated_keys DSN parameter")
+	errNotInsertStatement = errors.New("not an INSERT statement")
 )
 
 type Result struct {
-	cn       *Connection
 	affected int64
+	// keys are the keys generated by an INSERT statement, fetched right
+	// after its execution when enabled; nil for other statements
+	keys      []int64
+	keyColumn string
+	keysErr   error
 }
 
-func (r Result) LastInsertId() (lastId int64, err error) {
-	var rs *ResultSet
-	rs, err = r.cn.DirectQuery("SELECT LAST_IDENTITY()")
+// LastInsertId returns the key generated for the last row inserted by the
+// statement.
+func (r Result) LastInsertId() (int64, error) {
+	keys, err := r.GeneratedKeys()
 	if err != nil {
-		return
+		return 0, err
 	}
-	row, err := rs.Next()
-	if err != nil {
-		return
+	if len(keys) == 0 {
+		return 0, errNoGeneratedKeys
 	}
-	lastId = int64(row[0].(int))
-	return
+	return keys[len(keys)-1], nil
+}
+
+// GeneratedKeys returns the keys generated by the statement, one per inserted
+// row, in insertion order.
+func (r Result) GeneratedKeys() ([]int64, error) {
+	if r.keysErr != nil {
+		return nil, r.keysErr
+	}
+	if r.keys == nil {
+		return nil, errNoGeneratedKeys
+	}
+	return r.keys, nil
 }
 
 func (r Result) RowsAffected() (int64, error) {
@@ -55,8 +72,13 @@ func (noRows) RowsAffected() (int64, error) {
 	return 0, errNoRowsAffected
 }
 
+// Close releases the stream columns returned by the rows, which cannot be
+// read afterwards.
 func (r *Rows) Close() error {
-	return nil
+	if r.rs == nil {
+		return nil
+	}
+	return r.rs.releaseStreams()
 }
 
 func (r *Rows) Columns() []string {
@@ -88,7 +110,19 @@ func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
 	if !ok {
 		return reflect.TypeOf("")
 	}
-	return scanType(SQLTYPE(column.column_type))
+	var cd codec
+	if r.cn != nil {
+		cd = r.cn.codec
+	}
+	switch SQLTYPE(column.column_type) {
+	case LONGVARCHAR, LONGVARBINARY:
+		if r.cn != nil && r.cn.streamLOBs {
+			return reflect.TypeOf(&Stream{})
+		}
+	case VECTOR:
+		return vectorScanType(column.vectorType())
+	}
+	return cd.typeMapping(SQLTYPE(column.column_type)).scanType()
 }
 
 func (r *Rows) ColumnTypeNullable(index int) (nullable, ok bool) {
@@ -127,11 +161,24 @@ func (r *Rows) ColumnTypeLength(index int) (length int64, ok bool) {
 	switch SQLTYPE(column.column_type) {
 	case CHAR, VARCHAR, LONGVARCHAR, WCHAR, WVARCHAR, WLONGVARCHAR, GUID, BINARY, VARBINARY, LONGVARBINARY:
 		return int64(column.precision), true
+	case VECTOR:
+		// The dimension of the vector
+		return int64(column.precision), true
 	default:
 		return 0, false
 	}
 }
 
+// ColumnTypeVector returns the element type and dimension of a VECTOR
+// column. It reports false for other columns.
+func (r *Rows) ColumnTypeVector(index int) (elemType VectorType, dimension int, ok bool) {
+	column, ok := r.column(index)
+	if !ok || SQLTYPE(column.column_type) != VECTOR {
+		return "", 0, false
+	}
+	return column.vectorType(), column.precision, true
+}
+
 func (r *Rows) column(index int) (Column, bool) {
 	if r == nil || r.rs == nil || index < 0 || index >= len(r.rs.columns) {
 		return Column{}, false
@@ -139,74 +186,6 @@ func (r *Rows) column(index int) (Column, bool) {
 	return r.rs.columns[index], true
 }
 
-func databaseTypeName(colType SQLTYPE) string {
-	switch colType {
-	case GUID:
-		return "GUID"
-	case WLONGVARCHAR:
-		return "WLONGVARCHAR"
-	case WVARCHAR:
-		return "WVARCHAR"
-	case WCHAR:
-		return "WCHAR"
-	case BIT:
-		return "BIT"
-	case TINYINT:
-		return "TINYINT"
-	case BIGINT:
-		return "BIGINT"
-	case LONGVARBINARY:
-		return "LONGVARBINARY"
-	case VARBINARY:
-		return "VARBINARY"
-	case BINARY:
-		return "BINARY"
-	case LONGVARCHAR:
-		return "LONGVARCHAR"
-	case CHAR:
-		return "CHAR"
-	case NUMERIC:
-		return "NUMERIC"
-	case DECIMAL:
-		return "DECIMAL"
-	case INTEGER:
-		return "INTEGER"
-	case SMALLINT:
-		return "SMALLINT"
-	case FLOAT:
-		return "FLOAT"
-	case REAL:
-		return "REAL"
-	case DOUBLE:
-		return "DOUBLE"
-	case DATE, TYPE_DATE:
-		return "DATE"
-	case TIME, TYPE_TIME:
-		return "TIME"
-	case TIMESTAMP, TYPE_TIMESTAMP, TIMESTAMP_POSIX:
-		return "TIMESTAMP"
-	default:
-		return ""
-	}
-}
-
-func scanType(colType SQLTYPE) reflect.Type {
-	switch colType {
-	case BIT:
-		return reflect.TypeOf(false)
-	case TINYINT, SMALLINT, INTEGER, BIGINT:
-		return reflect.TypeOf(int64(0))
-	case NUMERIC, DECIMAL, FLOAT, REAL, DOUBLE:
-		return reflect.TypeOf(float64(0))
-	case TIMESTAMP, TYPE_TIMESTAMP, TIMESTAMP_POSIX:
-		return reflect.TypeOf(time.Time{})
-	case BINARY, VARBINARY, LONGVARBINARY:
-		return reflect.TypeOf([]byte{})
-	default:
-		return reflect.TypeOf("")
-	}
-}
-
 func (r *Rows) Next(dest []driver.Value) (err error) {
 	row, err := r.rs.Next()
 	if err != nil {
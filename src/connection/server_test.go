@@ -0,0 +1,225 @@
+package connection
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/stretchr/testify/require"
+)
+
+// testConnection returns a connection to a fake server, which answers each
+// request with the replies of handle, and a function returning the requests
+// received so far.
+func testConnection(t *testing.T, handle func(req Message) []Message) (*Connection, func() []Message) {
+	t.Helper()
+	ln, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var (
+		mu       sync.Mutex
+		requests []Message
+	)
+	go func() {
+		conn, err := ln.AcceptTCP()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			req, err := ReadMessage(conn)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			requests = append(requests, req)
+			mu.Unlock()
+			for _, reply := range handle(req) {
+				if _, err = conn.Write(reply.Dump(0)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	conn, err := net.DialTCP("tcp", nil, ln.Addr().(*net.TCPAddr))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	c := &Connection{
+		conn:            conn,
+		maxRowsPerFetch: DefaultMaxRowsPerFetch,
+		streamThreshold: DefaultStreamThreshold,
+		encoding:        &list.Encoding{Unicode: true},
+	}
+	return c, func() []Message {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+// requestType returns the message type of a request.
+func requestType(req Message) MessageType {
+	return MessageType(req.header.header[12:14])
+}
+
+// requestItems returns the list items of a request.
+func requestItems(req Message) []interface{} {
+	values, _ := list.List(req.data).Values()
+	return values
+}
+
+// reply returns a reply with the status and the values as list items.
+func reply(status uint16, values ...interface{}) []Message {
+	return []Message{replyMessage(status, values...)}
+}
+
+func replyMessage(status uint16, values ...interface{}) Message {
+	var msg Message
+	msg.header.header[12] = byte(status)
+	msg.header.header[13] = byte(status >> 8)
+	for _, value := range values {
+		msg.Set(value)
+	}
+	return msg
+}
+
+// resultSet returns the replies to a query whose result set has the columns
+// and the rows, without fast select.
+func resultSet(columns [][]interface{}, rows ...[]interface{}) []Message {
+	header := []interface{}{0, len(columns)}
+	for _, column := range columns {
+		header = append(header, column...)
+	}
+	header = append(header, 0, 0)
+	var data []interface{}
+	for _, row := range rows {
+		data = append(data, row...)
+	}
+	return []Message{replyMessage(0, header...), replyMessage(100, data...)}
+}
+
+// resultColumn returns the items describing a result set column of the type.
+func resultColumn(name string, coltype SQLTYPE) []interface{} {
+	return []interface{}{name, int(coltype), 10, 0, 1, name, "", "", "", "\x00\x00\x00\x00"}
+}
+
+// insertServer answers INSERT statements into Sample.Person, generating
+// PersonId 7, and queries of the inserted row.
+func insertServer(req Message) []Message {
+	switch requestType(req) {
+	case DIRECT_UPDATE:
+		return reply(0, 0, 0, 1)
+	case GET_AUTO_GENERATED_KEYS:
+		return generatedKeys(resultColumn("PersonId", INTEGER), 7)
+	case DIRECT_QUERY:
+		return resultSet([][]interface{}{resultColumn("PersonId", INTEGER), resultColumn("Name", VARCHAR)},
+			[]interface{}{7, "Ann"})
+	}
+	return reply(0)
+}
+
+// generatedKeys returns the reply to GET_AUTO_GENERATED_KEYS with the keys
+// in a result set column.
+func generatedKeys(column []interface{}, keys ...interface{}) []Message {
+	items := append([]interface{}{0, 1}, column...)
+	return reply(100, append(items, keys...)...)
+}
+
+// streamServer answers a query returning one LONGVARCHAR column with the
+// stream handles, and reads of the streams with their handle as content.
+// Streams with a handle starting with "long" take two chunks to read.
+func streamServer(handles ...string) func(req Message) []Message {
+	reads := map[string]int{}
+	return func(req Message) []Message {
+		switch requestType(req) {
+		case DIRECT_QUERY:
+			var rows [][]interface{}
+			for _, handle := range handles {
+				rows = append(rows, []interface{}{handle})
+			}
+			return resultSet([][]interface{}{resultColumn("Body", LONGVARCHAR)}, rows...)
+		case READ_STREAM:
+			handle := requestItems(req)[0].(string)
+			reads[handle]++
+			if strings.HasPrefix(handle, "long") && reads[handle] == 1 {
+				msg := replyMessage(0)
+				msg.AddRaw([]byte("start of " + handle))
+				return []Message{msg}
+			}
+			msg := replyMessage(100)
+			msg.AddRaw([]byte("content of " + handle))
+			return []Message{msg}
+		}
+		return reply(0)
+	}
+}
+
+func requestTypes(reqs []Message) []MessageType {
+	types := make([]MessageType, len(reqs))
+	for i, req := range reqs {
+		types[i] = requestType(req)
+	}
+	return types
+}
+
+// streamRequestItems returns the list items heading a stream request and the raw
+// bytes following them.
+func streamRequestItems(req Message, n int) ([]interface{}, []byte) {
+	var (
+		items  []interface{}
+		offset uint
+	)
+	for range n {
+		li := list.GetListItem(req.data, &offset)
+		value, _ := li.Value()
+		items = append(items, value)
+	}
+	return items, req.data[offset:]
+}
+
+// streamStore is a fake server stream with its handle, opened, written,
+// read and truncated at 1-based positions like %Stream objects.
+type streamStore struct {
+	handle  string
+	mu      sync.Mutex
+	content []byte
+}
+
+func (s *streamStore) serve(req Message) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch requestType(req) {
+	case OPEN_STREAM:
+		return reply(0, s.handle)
+	case STREAM_GET_POSITION:
+		return reply(0, 1)
+	case STREAM_SET_BYTES:
+		items, data := streamRequestItems(req, 2)
+		pos := int(items[1].(int64)) - 1
+		s.content = append(s.content[:min(pos, len(s.content))], data...)
+	case GET_STREAM_SIZE:
+		return reply(0, len(s.content))
+	case STREAM_GET_BYTES:
+		items := requestItems(req)
+		pos := min(int(items[1].(int64))-1, len(s.content))
+		end := min(pos+int(items[2].(int64)), len(s.content))
+		msg := replyMessage(0)
+		msg.AddRaw(s.content[pos:end])
+		return []Message{msg}
+	case STREAM_TRUNCATE:
+		s.content = s.content[:requestItems(req)[1].(int64)]
+	}
+	return reply(0)
+}
+
+// String returns the content of the stream.
+func (s *streamStore) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.content)
+}
@@ -0,0 +1,109 @@
+package connection
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ServerInfo describes the server of a connection, as reported in the
+// handshake and in the reply to CONNECT.
+type ServerInfo struct {
+	// ProtocolVersion is the protocol version agreed in the handshake.
+	ProtocolVersion uint16
+	// Unicode reports whether the server supports Unicode strings.
+	Unicode bool
+	// Locale is the server locale, such as "enuw".
+	Locale string
+	// VersionString is the $ZVERSION of the server, such as "IRIS for UNIX
+	// (Ubuntu Server LTS for x86-64 Containers) 2024.1 (Build 267.2U) Tue
+	// Apr 30 2024 16:06:39 EDT".
+	VersionString string
+	// Version is VersionString parsed.
+	Version Version
+	// DelimitedIDs reports whether the server treats quoted names as
+	// delimited identifiers.
+	DelimitedIDs bool
+	// IsolationLevel is the default isolation level reported by the server.
+	IsolationLevel int
+	// JobNumber is the process ID of the server process of the connection,
+	// the $JOB listed by %SYS.ProcessQuery.
+	JobNumber string
+	// SQLEmptyString is the empty string setting reported by the server.
+	SQLEmptyString int
+	// FeatureOptions are the features negotiated with the server.
+	FeatureOptions FeatureOption
+}
+
+// Version is an IRIS version, such as 2024.1.0 build 267.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	Build int
+}
+
+// versionPattern matches the version in $ZVERSION, such as "2024.1 (Build
+// 267.2U)" or "2022.1.0L (Build 209U)".
+var versionPattern = regexp.MustCompile(`\b(\d{4})\.(\d+)(?:\.(\d+))?[A-Za-z]*(?:\s+\(Build (\d+))?`)
+
+// ParseVersion parses the version from a $ZVERSION string, as returned by
+// ServerVersion.
+func ParseVersion(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("no IRIS version in %q", s)
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	if m[4] != "" {
+		v.Build, _ = strconv.Atoi(m[4])
+	}
+	return v, nil
+}
+
+// AtLeast reports whether the version is major.minor or later.
+func (v Version) AtLeast(major, minor int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.Minor >= minor
+}
+
+// String returns the version as major.minor.patch, followed by the build if
+// known, such as "2024.1.0 (Build 267)".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Build != 0 {
+		s += fmt.Sprintf(" (Build %d)", v.Build)
+	}
+	return s
+}
+
+// ServerInfo returns the description of the server. The version is taken
+// from the reply to CONNECT, or else asked with ServerVersion.
+func (c *Connection) ServerInfo() (ServerInfo, error) {
+	info := c.serverInfo
+	info.ProtocolVersion = c.version
+	info.Unicode = c.unicode
+	info.Locale = c.locale
+	info.FeatureOptions = c.featureOptions
+	version, err := ParseVersion(c.info)
+	if err != nil {
+		versionString, err := c.ServerVersion()
+		if err != nil {
+			return info, err
+		}
+		if version, err = ParseVersion(versionString); err != nil {
+			return info, err
+		}
+		c.info = versionString
+	}
+	info.VersionString = c.info
+	info.Version = version
+	return info, nil
+}
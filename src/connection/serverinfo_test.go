@@ -0,0 +1,62 @@
+package connection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	for _, tc := range []struct {
+		text    string
+		version Version
+	}{
+		{"IRIS for UNIX (Ubuntu Server LTS for x86-64 Containers) 2024.1 (Build 267.2U) Tue Apr 30 2024 16:06:39 EDT", Version{2024, 1, 0, 267}},
+		{"IRIS for Windows (x86-64) 2022.1.2 (Build 574U) Fri Jan 13 2023 15:08:27 EST", Version{2022, 1, 2, 574}},
+		{"IRIS for UNIX (Ubuntu Server LTS for ARM64 Containers) 2025.1.0L (Build 223U) Tue Mar 11 2025 18:14:42 EDT", Version{2025, 1, 0, 223}},
+		{"2023.3", Version{2023, 3, 0, 0}},
+	} {
+		version, err := ParseVersion(tc.text)
+		require.NoError(t, err, tc.text)
+		assert.Equal(t, tc.version, version, tc.text)
+	}
+	_, err := ParseVersion("IRIS")
+	assert.Error(t, err)
+
+	v := Version{2024, 1, 0, 267}
+	assert.Equal(t, "2024.1.0 (Build 267)", v.String())
+	assert.Equal(t, "2023.3.0", Version{2023, 3, 0, 0}.String())
+	assert.True(t, v.AtLeast(2024, 1))
+	assert.True(t, v.AtLeast(2023, 3))
+	assert.False(t, v.AtLeast(2024, 2))
+	assert.False(t, v.AtLeast(2025, 1))
+}
+
+func TestServerInfo(t *testing.T) {
+	c := &Connection{
+		version:        VERSION_PROTOCOL,
+		unicode:        true,
+		locale:         "enuw",
+		info:           "IRIS for UNIX (Ubuntu Server LTS for x86-64 Containers) 2024.1 (Build 267.2U) Tue Apr 30 2024 16:06:39 EDT",
+		featureOptions: DefaultFeatureOptions,
+		serverInfo: ServerInfo{
+			DelimitedIDs:   true,
+			IsolationLevel: 1,
+			JobNumber:      "4242",
+		},
+	}
+	info, err := c.ServerInfo()
+	require.NoError(t, err)
+	assert.Equal(t, ServerInfo{
+		ProtocolVersion: VERSION_PROTOCOL,
+		Unicode:         true,
+		Locale:          "enuw",
+		VersionString:   c.info,
+		Version:         Version{2024, 1, 0, 267},
+		DelimitedIDs:    true,
+		IsolationLevel:  1,
+		JobNumber:       "4242",
+		FeatureOptions:  DefaultFeatureOptions,
+	}, info)
+}
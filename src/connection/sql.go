@@ -4,12 +4,18 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/caretdev/go-irisnative/src/horolog"
 	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/shopspring/decimal"
 )
 
 const timeLayout = "2006-01-02 15:04:05.000000000"
@@ -25,6 +31,12 @@ const DefaultMaxRowsPerFetch = 0
 // A value of 0 means no timeout.
 const DefaultQueryTimeout = 0
 
+// DefaultStreamThreshold is the size in bytes above which string and []byte
+// parameters are uploaded as streams, the largest LONGVARCHAR value sent
+// inline. This can be configured via the DSN parameter "stream_threshold".
+// A value of 0 means never.
+const DefaultStreamThreshold = 32000
+
 type StatementFeature struct {
 	featureOption   int
 	msgCount        int
@@ -81,6 +93,7 @@ const (
 	DATE_HOROLOG    SQLTYPE = 1091
 	TIME_HOROLOG    SQLTYPE = 1092
 	TIMESTAMP_POSIX SQLTYPE = 1093
+	VECTOR          SQLTYPE = 102
 )
 
 func (c Column) Name() string {
@@ -95,6 +108,20 @@ type ResultSet struct {
 	data    []byte
 	offset  uint
 	sqlCode int16
+	// streams are the stream columns returned in stream mode, released when
+	// the rows are closed
+	streams []*Stream
+}
+
+// releaseStreams releases the stream columns returned so far.
+func (rs *ResultSet) releaseStreams() (err error) {
+	for _, stream := range rs.streams {
+		if rerr := stream.release(); err == nil {
+			err = rerr
+		}
+	}
+	rs.streams = nil
+	return
 }
 
 type SQLError struct {
@@ -136,37 +163,13 @@ type Value interface{}
 
 // type ResultSetRow struct{}
 
-func (c *Connection) fetchStream(handle string, binary bool) (result interface{}, err error) {
-	var statementId = c.statementId()
-	msg := NewMessage(READ_STREAM)
-	msg.header.SetStatementId(statementId)
-	msg.Set(handle)
-	msg.Set(-1)
-	_, err = c.conn.Write(msg.Dump(c.count()))
-	if err != nil {
-		return
-	}
-	msg, err = ReadMessage(c.conn)
-	if err != nil {
-		return
-	}
-	var data []byte
-	msg.GetRaw(&data)
-	result = data
-	if !binary {
-		result = string(data)
-	}
-
-	return
-}
-
 func (rs *ResultSet) fetchMoreData() (bool, error) {
-	msg := NewMessage(FETCH_DATA)
-	_, err := rs.c.conn.Write(msg.Dump(rs.c.count()))
+	msg := rs.c.newMessage(FETCH_DATA)
+	err := rs.c.write(msg)
 	if err != nil {
 		return false, err
 	}
-	msg, err = ReadMessage(rs.c.conn)
+	msg, err = rs.c.readMessage()
 	if err != nil {
 		return false, err
 	}
@@ -186,94 +189,63 @@ func (rs *ResultSet) fetchMoreData() (bool, error) {
 	return len(msg.data) > 0, nil
 }
 
-func fromODBC(coltype SQLTYPE, li list.ListItem) (result interface{}, err error) {
-	result = nil
-	if li.IsNull() || li.IsEmpty() {
-		return
+func (cd codec) fromODBC(coltype SQLTYPE, li list.ListItem) (interface{}, error) {
+	return cd.fromColumn(Column{column_type: int(coltype)}, li)
+}
+
+// fromColumn decodes a value of the column, whose metadata some types, like
+// VECTOR, need beyond the SQL type.
+func (cd codec) fromColumn(column Column, li list.ListItem) (interface{}, error) {
+	coltype := SQLTYPE(column.column_type)
+	if li.IsNull() {
+		return nil, nil
 	}
-	switch coltype {
-	case LONGVARCHAR:
-		if li.DataLength() == 0 {
-			return
-		}
-		var value string
-		li.Get(&value)
-		result = value
-	case GUID:
-		if li.DataLength() == 0 {
-			return
+	if decoder, ok := cd.registry.decoder(coltype); ok {
+		return decoder(li)
+	}
+	if li.IsEmpty() {
+		return nil, nil
+	}
+	return cd.typeMapping(coltype).decode(cd, column, li)
+}
+
+// dateFromODBC decodes a date sent either as an ODBC date string or as a
+// $HOROLOG day count, as midnight in loc.
+func dateFromODBC(li list.ListItem, loc *time.Location) (time.Time, error) {
+	if li.IsString() {
+		var strval string
+		if err := li.Get(&strval); err != nil {
+			return time.Time{}, err
 		}
-		var value string
-		li.Get(&value)
-		result = value
-	case VARCHAR:
-		if li.DataLength() == 0 {
-			return
+		if strings.Contains(strval, "-") {
+			return time.ParseInLocation(horolog.ODBCDate, strval, loc)
 		}
-		var value string
-		li.Get(&value)
-		if value == "\x00" {
-			value = ""
-		}
-		result = value
-	case INTEGER, TINYINT, SMALLINT:
-		var value int
-		li.Get(&value)
-		result = value
-	case BIGINT:
-		var value int64
-		li.Get(&value)
-		result = value
-	case BIT:
-		var value bool
-		li.Get(&value)
-		result = value
-	case FLOAT, REAL:
-		var value float32
-		err = li.Get(&value)
-		result = value
-	case NUMERIC, DECIMAL, DOUBLE:
-		var value float64
-		err = li.Get(&value)
-		result = value
-	case TIMESTAMP_POSIX:
-		if li.DataLength() == 0 {
-			return
+	}
+	var days int
+	if err := li.Get(&days); err != nil {
+		return time.Time{}, err
+	}
+	return horolog.DateOf(days, loc), nil
+}
+
+// timeFromODBC decodes a time of day sent either as an ODBC time string or
+// as seconds since midnight, possibly fractional. The result is on January 1
+// of year 0 in loc, as with time.Parse.
+func timeFromODBC(li list.ListItem, loc *time.Location) (time.Time, error) {
+	if li.IsString() {
+		var strval string
+		if err := li.Get(&strval); err != nil {
+			return time.Time{}, err
 		}
-		if li.Type() == list.LISTITEM_STRING {
-			var strval string
-			li.Get(&strval)
-			result, err = time.Parse(timeLayout, strval)
-			if err == nil {
-				return
-			}
-			err = nil
+		if strings.Contains(strval, ":") {
+			return time.ParseInLocation(horolog.ODBCTime, strval, loc)
 		}
-		var value int64
-		li.Get(&value)
-		if value > 0 {
-			value ^= 0x1000000000000000
-		} else {
-			value |= 0x6000000000000000
-		}
-		seconds := value / 1000000
-		nano := value % 1000000 * 1000
-		result = time.Unix(seconds, nano).In(time.Local)
-	case VARBINARY:
-		// var value []uint8
-		var value string
-		li.Get(&value)
-	case TYPE_TIMESTAMP:
-		var strval string
-		li.Get(&strval)
-		result, err = time.Parse(timeLayoutShort, strval)
-	default:
-		var value string
-		li.Get(&value)
-		// fmt.Printf("fromODBC: invalid type: %v - %#v - %#v", coltype, li, value)
-		result = value
 	}
-	return
+	var seconds float64
+	if err := li.Get(&seconds); err != nil {
+		return time.Time{}, err
+	}
+	return horolog.TimeOf(seconds, loc), nil
 }
 
 func (rs *ResultSet) Next() ([]Value, error) {
@@ -293,39 +265,34 @@ func (rs *ResultSet) Next() ([]Value, error) {
 		}
 	}
 	row := make([]Value, rs.count)
-	data := rs.data
-	count := rs.count
-	var offset uint = rs.offset
-	if rs.sf.featureOption == 1 {
-		li := list.GetListItem(data, &rs.offset)
-		li.Get(&data)
-		offset = 0
-		count = rs.sf.maxRowItemCount
-	}
-	vals := make([]list.ListItem, count)
-	for i := 0; i < count; i++ {
-		li := list.GetListItem(data, &offset)
-		vals[i] = li
-	}
-	if rs.sf.featureOption != 1 {
-		rs.offset = offset
-	}
+	vals := rs.rowItems()
 	var err error
 	conn := rs.c
 	for i, c := range rs.columns {
+		if c.slot_position < 0 || c.slot_position >= len(vals) {
+			return nil, fmt.Errorf("column %s: slot %d not in row of %d items", c.name, c.slot_position+1, len(vals))
+		}
 		li := vals[c.slot_position]
 		value := interface{}(nil)
 		coltype := SQLTYPE(c.column_type)
-		value, err = fromODBC(coltype, li)
+		value, err = conn.codec.fromColumn(c, li)
 		if err != nil {
 			return nil, err
 		}
-		if value != nil {
+		if handle, ok := value.(string); ok {
 			switch coltype {
-			case LONGVARCHAR:
-				value, err = conn.fetchStream(value.(string), false)
-			case LONGVARBINARY:
-				// value, err = conn.fetchStream(value.(string), true)
+			case LONGVARCHAR, LONGVARBINARY:
+				binary := coltype == LONGVARBINARY
+				if !conn.streamLOBs {
+					value, err = conn.readStream(handle, binary)
+					break
+				}
+				stream := conn.newStream(handle, binary)
+				var open bool
+				if value, open, err = stream.value(); open {
+					stream.lease = &streamLease{}
+					rs.streams = append(rs.streams, stream)
+				}
 			}
 		}
 		if err != nil {
@@ -339,14 +306,35 @@ func (rs *ResultSet) Next() ([]Value, error) {
 	return row, nil
 }
 
+// rowItems reads the items of the next row from the data. With fast select
+// a row is one item holding a $LIST of maxRowItemCount items, which columns
+// refer to by slot position; otherwise the row is made of one item per
+// column, in column order, directly in the data.
+func (rs *ResultSet) rowItems() []list.ListItem {
+	data := rs.data
+	count := rs.count
+	offset := &rs.offset
+	if rs.sf.featureOption == int(OptionFastSelect) {
+		li := rs.c.encoding.GetListItem(data, &rs.offset)
+		li.Get(&data)
+		offset = new(uint)
+		count = rs.sf.maxRowItemCount
+	}
+	vals := make([]list.ListItem, count)
+	for i := range vals {
+		vals[i] = rs.c.encoding.GetListItem(data, offset)
+	}
+	return vals
+}
+
 func (c *Connection) getErrorInfo(sqlCode int16) (string, error) {
-	msg := NewMessage(GET_SERVER_ERROR)
+	msg := c.newMessage(GET_SERVER_ERROR)
 	msg.Set(sqlCode)
-	_, err := c.conn.Write(msg.Dump(c.count()))
+	err := c.write(msg)
 	if err != nil {
 		return "", err
 	}
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return "", err
 	}
@@ -405,7 +393,7 @@ func parameterInfo(msg *Message) {
 	msg.Get(&flag)
 }
 
-func toODBC(value interface{}) interface{} {
+func (cd codec) toODBC(value interface{}) interface{} {
 	var val interface{}
 	switch v := value.(type) {
 	case *string:
@@ -424,31 +412,64 @@ func toODBC(value interface{}) interface{} {
 			val = 0
 		}
 	case time.Time:
-		val = v.UTC().Format(timeLayout)
+		val = cd.formatTime(v)
 	case int, int8, int16, int32, int64:
 		val = v
+	case uint, uint8, uint16, uint32, uint64, *big.Int:
+		val = v
 	case float32, float64:
 		val = v
+	case decimal.Decimal:
+		val = v
 	case []uint8:
 		val = v
+		if len(v) == 0 {
+			val = []byte{0}
+		}
+	case streamHandle:
+		val = v.handle
+	case Vector, []int32, []int64, []float32, []float64:
+		// Sent in the text form, which the server converts to the vector
+		// announced by parameterType
+		vector, _ := NewVector(v)
+		val = vector.String()
 	default:
-		fmt.Printf("unsupported type: %T\n", v)
-		val = fmt.Sprintf("%v", v)
+		// checkValue rejects any other type before parameters are encoded,
+		// and bindStreams replaces readers by their handles
+		val = v
 	}
 	return val
 }
 
-func writeParameters(msg *Message, args ...interface{}) {
+// parameterType returns the SQL type announced for a parameter. Binary values
+// and stream handles are announced so the server stores them without
+// character conversion; the type of anything else is inferred by the server.
+func parameterType(value interface{}) int {
+	switch v := value.(type) {
+	case []byte:
+		return int(VARBINARY)
+	case Vector, []int32, []int64, []float32, []float64:
+		return int(VECTOR)
+	case streamHandle:
+		if v.binary {
+			return int(LONGVARBINARY)
+		}
+		return int(LONGVARCHAR)
+	}
+	return 99
+}
+
+func (cd codec) writeParameters(msg *Message, args ...interface{}) {
 	msg.Set(len(args))
-	for range args {
-		msg.Set(99)
+	for _, arg := range args {
+		msg.Set(parameterType(arg))
 		msg.Set(4)
 	}
 
 	msg.Set(1) // parameterSets
 	msg.Set(len(args))
 	for _, arg := range args {
-		msg.Set(toODBC(arg))
+		msg.Set(cd.toODBC(arg))
 	}
 }
 
@@ -485,7 +506,9 @@ func (c *Connection) Query(sqlText string, args ...interface{}) (rs *ResultSet,
 	}
 
 	// Route to DirectUpdate for DDL/DML statements, DirectQuery for SELECT
-	if isUpdateQuery(sqlText) {
+	if insert, returning, ok := splitReturning(sqlText); ok {
+		rs, err = c.InsertReturning(insert, returning, args...)
+	} else if isUpdateQuery(sqlText) {
 		_, err = c.DirectUpdate(sqlText, args...)
 		if err != nil {
 			return
@@ -505,22 +528,30 @@ func (c *Connection) Query(sqlText string, args ...interface{}) (rs *ResultSet,
 }
 
 func (c *Connection) DirectQuery(sqlText string, args ...interface{}) (*ResultSet, error) {
-	sqlText, _, args = FormatQuery(sqlText, args...)
+	sqlText, _, args = formatQuery(sqlText, !c.noSliceExpansion, args...)
+	args, err := c.codec.checkValues(args)
+	if err != nil {
+		return nil, err
+	}
+	args, err = c.bindStreams(args)
+	if err != nil {
+		return nil, err
+	}
 	// fmt.Printf("DirectQuery: %s; %#v\n", sqlText, args)
 
 	var statementId = c.statementId()
-	msg := NewMessage(DIRECT_QUERY)
+	msg := c.newMessage(DIRECT_QUERY)
 	msg.header.SetStatementId(statementId)
 	msg.SetSQLText(sqlText)
-	writeParameters(&msg, args...)
+	c.codec.writeParameters(&msg, args...)
 	msg.Set(c.queryTimeout)    // Query timeout
 	msg.Set(c.maxRowsPerFetch) // Max rows
 
-	_, err := c.conn.Write(msg.Dump(c.count()))
+	err = c.write(msg)
 	if err != nil {
 		return nil, err
 	}
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	if err != nil {
 		return nil, err
 	}
@@ -542,7 +573,7 @@ func (c *Connection) DirectQuery(sqlText string, args ...interface{}) (*ResultSe
 		count:   len(columns),
 	}
 
-	msg, err = ReadMessage(c.conn)
+	msg, err = c.readMessage()
 	rs.sqlCode = int16(msg.GetStatus())
 	if err != nil {
 		return nil, err
@@ -564,16 +595,83 @@ func (m Message) debug() string {
 	return fmt.Sprintf("$char(%s)", sb.String())
 }
 
+// FormatQuery replaces the ? placeholders of sqlText with IRIS parameters and
+// returns the number of parameters per row. Arguments past the placeholders
+// are further rows of a batch. A slice bound to the only placeholder of
+// IN (?) is expanded into one parameter per element, unless the statement is
+// a batch.
 func FormatQuery(sqlText string, args ...interface{}) (string, int, []interface{}) {
-	var count int
-	for i := range args {
-		count++
-		sqlText = strings.Replace(sqlText, "?", fmt.Sprintf(" :%%qpar(%d) ", i+1), 1)
-		if !strings.Contains(sqlText, "?") {
+	return formatQuery(sqlText, true, args...)
+}
+
+var (
+	inListStart = regexp.MustCompile(`(?i)\bIN\s*\(\s*$`)
+	inListEnd   = regexp.MustCompile(`^\s*\)`)
+)
+
+func formatQuery(sqlText string, expand bool, args ...interface{}) (string, int, []interface{}) {
+	if expand && strings.Count(sqlText, "?") < len(args) {
+		expand = false
+	}
+	var (
+		b      strings.Builder
+		count  int
+		params []interface{}
+	)
+	rest := sqlText
+	for i, arg := range args {
+		pos := strings.IndexByte(rest, '?')
+		if pos < 0 {
+			if count == 0 {
+				count = 1
+			}
+			params = append(params, args[i:]...)
+			break
+		}
+		b.WriteString(rest[:pos])
+		rest = rest[pos+1:]
+		elems, ok := expandSlice(arg)
+		if !expand || !ok || !inListStart.MatchString(b.String()) || !inListEnd.MatchString(rest) {
+			count++
+			fmt.Fprintf(&b, " :%%qpar(%d) ", count)
+			params = append(params, arg)
+		} else if len(elems) == 0 {
+			// IN () is not valid, IN (NULL) matches nothing alike
+			b.WriteString("NULL")
+		} else {
+			for j, elem := range elems {
+				if j > 0 {
+					b.WriteByte(',')
+				}
+				count++
+				fmt.Fprintf(&b, " :%%qpar(%d) ", count)
+				params = append(params, elem)
+			}
+		}
+		if !strings.Contains(rest, "?") {
+			params = append(params, args[i+1:]...)
 			break
 		}
 	}
-	return sqlText, count, args
+	b.WriteString(rest)
+	return b.String(), count, params
+}
+
+// expandSlice returns the elements of slices that can be bound to IN (?):
+// slices of anything but bytes that are not driver.Valuer.
+func expandSlice(arg interface{}) ([]interface{}, bool) {
+	if _, ok := arg.(driver.Valuer); ok {
+		return nil, false
+	}
+	rv := reflect.ValueOf(arg)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	elems := make([]interface{}, rv.Len())
+	for i := range elems {
+		elems[i] = rv.Index(i).Interface()
+	}
+	return elems, true
 }
 
 func (c *Connection) Exec(sqlText string, args ...interface{}) (res *Result, err error) {
@@ -591,7 +689,7 @@ func (c *Connection) Exec(sqlText string, args ...interface{}) (res *Result, err
 	res, err = c.DirectUpdate(sqlText, args...)
 	if err != nil {
 		if strings.Contains(onConflict, "ON CONFLICT DO NOTHING") {
-			res = &Result{cn: c, affected: 0}
+			res = &Result{affected: 0}
 			err = nil
 			return
 		}
@@ -600,9 +698,23 @@ func (c *Connection) Exec(sqlText string, args ...interface{}) (res *Result, err
 }
 
 func (c *Connection) DirectUpdate(sqlText string, args ...interface{}) (*Result, error) {
+	return c.directUpdate(sqlText, c.fetchKeys, args...)
+}
+
+// directUpdate runs an update statement; the keys generated by INSERT
+// statements are fetched when fetchKeys is set.
+func (c *Connection) directUpdate(sqlText string, fetchKeys bool, args ...interface{}) (*Result, error) {
 	var batchSize int
-	sqlText, batchSize, args = FormatQuery(sqlText, args...)
+	sqlText, batchSize, args = formatQuery(sqlText, !c.noSliceExpansion, args...)
 	// fmt.Printf("DirectUpdate: %s; %#v\n", sqlText, args)
+	args, err := c.codec.checkValues(args)
+	if err != nil {
+		return nil, err
+	}
+	args, err = c.bindStreams(args)
+	if err != nil {
+		return nil, err
+	}
 	var batches = 1
 	if batchSize > 0 {
 		batches = len(args) / batchSize
@@ -614,17 +726,22 @@ func (c *Connection) DirectUpdate(sqlText string, args ...interface{}) (*Result,
 	var rowsAffected int64 = 0
 	var identityColumn = false
 	var defaults = []interface{}{}
+	var insert = isInsertQuery(sqlText)
+	var collectKeys = insert && fetchKeys
+	var keys []int64
+	var keyColumn string
+	var keysErr error
 	for i := 1; i <= batches; i++ {
 		if i > 1 && executeMany {
 			break
 		}
 		var msg Message
 		if !addToCache {
-			msg = NewMessage(DIRECT_UPDATE)
+			msg = c.newMessage(DIRECT_UPDATE)
 			msg.SetSQLText(sqlText)
 			msg.Set(batchSize)
 			for j := 0; j < batchSize; j++ {
-				msg.Set(99)
+				msg.Set(parameterType(args[j]))
 				msg.Set(1)
 			}
 			// msg.Set(len(args))
@@ -633,7 +750,7 @@ func (c *Connection) DirectUpdate(sqlText string, args ...interface{}) (*Result,
 			// 	msg.Set(1)
 			// }
 		} else {
-			msg = NewMessage(PREPARED_UPDATE)
+			msg = c.newMessage(PREPARED_UPDATE)
 		}
 		if addToCache && !executeMany && optFastInsert {
 			msg.AddRaw([]byte{1, 0, 0, 0})
@@ -651,11 +768,15 @@ func (c *Connection) DirectUpdate(sqlText string, args ...interface{}) (*Result,
 			var params []byte
 			var item list.ListItem
 			for _, arg := range batch {
-				item = list.NewListItem(toODBC(arg))
+				if item, err = c.encoding.NewListItem(c.codec.toODBC(arg)); err != nil {
+					return nil, err
+				}
 				params = append(params, item.Dump()...)
 			}
 			for _, arg := range defaults {
-				item = list.NewListItem(toODBC(arg))
+				if item, err = c.encoding.NewListItem(c.codec.toODBC(arg)); err != nil {
+					return nil, err
+				}
 				params = append(params, item.Dump()...)
 			}
 			msg.Set(params)
@@ -668,7 +789,7 @@ func (c *Connection) DirectUpdate(sqlText string, args ...interface{}) (*Result,
 					msg.Set(batchSize)
 					for j := 0; j < batchSize; j++ {
 						var idx = (k * batchSize) + j
-						msg.Set(toODBC(args[idx]))
+						msg.Set(c.codec.toODBC(args[idx]))
 					}
 				}
 			} else {
@@ -678,17 +799,17 @@ func (c *Connection) DirectUpdate(sqlText string, args ...interface{}) (*Result,
 				msg.Set(1)
 				msg.Set(len(batch))
 				for _, arg := range batch {
-					msg.Set(toODBC(arg))
+					msg.Set(c.codec.toODBC(arg))
 				}
 			}
 		}
 
 		msg.header.SetStatementId(statementId)
-		_, err := c.conn.Write(msg.Dump(c.count()))
+		err := c.write(msg)
 		if err != nil {
 			return nil, err
 		}
-		msg, err = ReadMessage(c.conn)
+		msg, err = c.readMessage()
 		if err != nil {
 			// fmt.Println("DirectUpdate:Readmessage: ", err)
 			return nil, err
@@ -711,11 +832,196 @@ func (c *Connection) DirectUpdate(sqlText string, args ...interface{}) (*Result,
 		var batchRows int64
 		msg.Get(&batchRows)
 		rowsAffected += batchRows
+		if collectKeys && keysErr == nil {
+			// The server only keeps the keys of the last execution of the
+			// statement, so they are fetched right after each batch
+			var batchKeys []int64
+			batchKeys, keyColumn, keysErr = c.generatedKeys(statementId)
+			keys = append(keys, batchKeys...)
+		}
+	}
+	result := &Result{affected: rowsAffected, keys: keys, keyColumn: keyColumn, keysErr: keysErr}
+	switch {
+	case insert && !fetchKeys:
+		result.keysErr = errKeysNotFetched
+	case collectKeys && keys == nil && keysErr == nil:
+		result.keys = []int64{}
 	}
-	result := &Result{cn: c, affected: rowsAffected}
 	return result, nil
 }
 
+func isInsertQuery(sqlText string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sqlText)), "INSERT")
+}
+
+var insertTableRegexp = regexp.MustCompile(`(?is)^\s*INSERT\s+(?:OR\s+UPDATE\s+)?INTO\s+((?:"[^"]*"|[^\s("])+)`)
+
+// insertTable returns the name of the table an INSERT statement targets.
+func insertTable(sqlText string) (string, bool) {
+	match := insertTableRegexp.FindStringSubmatch(sqlText)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// GeneratedKeys requests the keys generated by the last execution of the
+// update statement statementId, using GET_AUTO_GENERATED_KEYS. The result set
+// has one row per inserted row.
+func (c *Connection) GeneratedKeys(statementId uint32) (*ResultSet, error) {
+	msg := c.newMessage(GET_AUTO_GENERATED_KEYS)
+	msg.header.SetStatementId(statementId)
+	err := c.write(msg)
+	if err != nil {
+		return nil, err
+	}
+	msg, err = c.readMessage()
+	if err != nil {
+		return nil, err
+	}
+	sqlCode := int16(msg.GetStatus())
+	if sqlCode != 0 && sqlCode != 100 {
+		msgStr, err := c.getErrorInfo(sqlCode)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &SQLError{SQLCode: sqlCode, Message: msgStr}
+	}
+	statementFeature := statementFeature(&msg)
+	columns := getColumns(&msg, statementFeature)
+	rs := &ResultSet{
+		c:       c,
+		sf:      statementFeature,
+		columns: columns,
+		count:   len(columns),
+		sqlCode: sqlCode,
+	}
+	msg.GetRaw(&rs.data)
+	return rs, nil
+}
+
+// generatedKeys reads the first column of the generated keys of statementId
+// as integers, and returns the name of that column.
+func (c *Connection) generatedKeys(statementId uint32) ([]int64, string, error) {
+	rs, err := c.GeneratedKeys(statementId)
+	if err != nil {
+		return nil, "", err
+	}
+	var column string
+	if len(rs.columns) > 0 {
+		column = rs.columns[0].name
+	}
+	keys := []int64{}
+	for {
+		row, err := rs.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, column, err
+		}
+		if len(row) == 0 || row[0] == nil {
+			continue
+		}
+		key, err := asInt64(row[0])
+		if err != nil {
+			return nil, column, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, column, nil
+}
+
+func asInt64(value Value) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float32:
+		return asInt64(float64(v))
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("generated key %v is not an integer", v)
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected key type %T", value)
+	}
+}
+
+// returningColumn matches the column names InsertReturning accepts: plain
+// or delimited identifiers and %ID.
+var returningColumn = regexp.MustCompile(`^(?:%?[A-Za-z][A-Za-z0-9_]*|"[^"]+")$`)
+
+// returningClause matches a trailing RETURNING clause of an INSERT statement.
+var returningClause = regexp.MustCompile(`(?is)\s+RETURNING\s+((?:%?[A-Za-z][A-Za-z0-9_]*|"[^"]+")(?:\s*,\s*(?:%?[A-Za-z][A-Za-z0-9_]*|"[^"]+"))*)\s*;?\s*$`)
+
+// splitReturning splits an INSERT statement ending with a RETURNING clause
+// into the statement and the returned columns.
+func splitReturning(sqlText string) (string, []string, bool) {
+	if !isInsertQuery(sqlText) {
+		return sqlText, nil, false
+	}
+	loc := returningClause.FindStringSubmatchIndex(sqlText)
+	if loc == nil {
+		return sqlText, nil, false
+	}
+	var columns []string
+	for _, column := range strings.Split(sqlText[loc[2]:loc[3]], ",") {
+		columns = append(columns, strings.TrimSpace(column))
+	}
+	return sqlText[:loc[0]], columns, true
+}
+
+// InsertReturning executes an INSERT statement and reads back the given
+// columns of the inserted rows, like the RETURNING clause of other databases.
+// The rows are located by the keys generated by the statement, in the key
+// column reported by the server, %ID by default. This makes it possible to
+// fetch ROWID, SERIAL and default values computed by the server. Queries
+// ending with "RETURNING column, ..." run through InsertReturning.
+func (c *Connection) InsertReturning(sqlText string, returning []string, args ...interface{}) (*ResultSet, error) {
+	table, ok := insertTable(sqlText)
+	if !ok {
+		return nil, errNotInsertStatement
+	}
+	if len(returning) == 0 {
+		returning = []string{"%ID"}
+	}
+	for _, column := range returning {
+		if !returningColumn.MatchString(column) {
+			return nil, fmt.Errorf("invalid returning column %q", column)
+		}
+	}
+	res, err := c.directUpdate(sqlText, true, args...)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := res.GeneratedKeys()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errNoGeneratedKeys
+	}
+	keyColumn := "%ID"
+	if returningColumn.MatchString(res.keyColumn) {
+		keyColumn = res.keyColumn
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (%s) ORDER BY %s",
+		strings.Join(returning, ", "), table, keyColumn, placeholders, keyColumn)
+	params := make([]interface{}, len(keys))
+	for i, key := range keys {
+		params[i] = key
+	}
+	return c.DirectQuery(query, params...)
+}
+
 func (c *Connection) checkStatementFeature(msg *Message) (featureOption uint, count uint) {
 	count = 0
 	var keyCount int
@@ -769,13 +1075,13 @@ func (c *Connection) getParameterInfo(msg *Message, optFastInsert bool) (addToCa
 		msg.Get(&paramsDefault)
 		var offset uint = 0
 		var li list.ListItem
-		li = list.GetListItem(paramsDefault, &offset)
+		li = c.encoding.GetListItem(paramsDefault, &offset)
 		identityColumn = li.IsEmpty()
 		for {
 			if uint(len(paramsDefault)) == offset {
 				break
 			}
-			li = list.GetListItem(paramsDefault, &offset)
+			li = c.encoding.GetListItem(paramsDefault, &offset)
 			if li.IsNull() {
 				continue
 			}
@@ -799,19 +1105,23 @@ func (c *Connection) Prepare(query string) (*Stmt, error) {
 	return st, nil
 }
 
+// Exec runs the statement with args that database/sql already converted with
+// CheckValue.
 func (st *Stmt) Exec(args []driver.Value) (res driver.Result, err error) {
 	parameters := make([]interface{}, len(args))
 	for i, a := range args {
-		parameters[i] = a
+		parameters[i] = st.cn.checked(a)
 	}
 	res, err = st.cn.Exec(st.sql, parameters...)
 	return
 }
 
+// Query runs the statement with args that database/sql already converted with
+// CheckValue.
 func (st *Stmt) Query(args []driver.Value) (rows driver.Rows, err error) {
 	parameters := make([]interface{}, len(args))
 	for i, a := range args {
-		parameters[i] = a
+		parameters[i] = st.cn.checked(a)
 	}
 	var rs *ResultSet
 	rs, err = st.cn.Query(st.sql, parameters...)
@@ -1,28 +1,35 @@
 package connection
 
 import (
+	"io"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/caretdev/go-irisnative/src/iris"
 	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestToODBC(t *testing.T) {
-	assert.Equal(t, 0, toODBC(false))
-	assert.Equal(t, 1, toODBC(true))
-	assert.Equal(t, "test", toODBC("test"))
-	assert.Equal(t, "", toODBC(nil))
-	assert.Equal(t, "\x00", toODBC(""))
-	assert.Equal(t, int(100), toODBC(int(100)))
-	assert.Equal(t, "2025-12-25 10:20:30.123456789", toODBC(time.Date(2025, time.December, 25, 10, 20, 30, 123456789, time.UTC)))
-	assert.Equal(t, "2025-09-16 21:07:58.043329000", toODBC(time.Date(2025, time.September, 16, 21, 7, 58, 43329000, time.UTC)))
+	assert.Equal(t, 0, codec{}.toODBC(false))
+	assert.Equal(t, 1, codec{}.toODBC(true))
+	assert.Equal(t, "test", codec{}.toODBC("test"))
+	assert.Equal(t, "", codec{}.toODBC(nil))
+	assert.Equal(t, "\x00", codec{}.toODBC(""))
+	assert.Equal(t, int(100), codec{}.toODBC(int(100)))
+	assert.Equal(t, decimal.RequireFromString("19.99"), codec{}.toODBC(decimal.RequireFromString("19.99")))
+	assert.Equal(t, "2025-12-25 10:20:30.123456789", codec{}.toODBC(time.Date(2025, time.December, 25, 10, 20, 30, 123456789, time.UTC)))
+	assert.Equal(t, "2025-09-16 21:07:58.043329000", codec{}.toODBC(time.Date(2025, time.September, 16, 21, 7, 58, 43329000, time.UTC)))
 }
 
 func mustFromODBC(coltype SQLTYPE, li list.ListItem) (result interface{}) {
 	var err error
-	result, err = fromODBC(coltype, li)
+	result, err = codec{}.fromODBC(coltype, li)
 	if err != nil {
 		panic("Error in mustFromODBC")
 	}
@@ -36,39 +43,44 @@ func TestFromODBC(t *testing.T) {
 	assert.Equal(t, false, mustFromODBC(BIT, list.NewListItem(false)))
 	assert.Equal(t, true, mustFromODBC(BIT, list.NewListItem(true)))
 	assert.Equal(t, "test", mustFromODBC(VARCHAR, list.NewListItem("test")))
-	assert.Equal(t, float64(42.5), mustFromODBC(NUMERIC, list.NewListItem("42.5")))
-	assert.Equal(t, float64(42.5), mustFromODBC(DECIMAL, list.NewListItem("42.5")))
+	assert.Equal(t, "42.5", mustFromODBC(NUMERIC, list.NewListItem("42.5")))
+	assert.Equal(t, "42.5", mustFromODBC(DECIMAL, list.NewListItem("42.5")))
+	assert.Equal(t, "1234567.89", mustFromODBC(NUMERIC, list.NewListItem(decimal.RequireFromString("1234567.89"))))
+	assert.Equal(t, "-0.01", mustFromODBC(NUMERIC, list.NewListItem(decimal.RequireFromString("-0.01"))))
+	value, err := codec{}.fromODBC(NUMERIC, list.NewListItem("not a number"))
+	assert.Error(t, err)
+	assert.Nil(t, value)
 	assert.Equal(t, float64(42.5), mustFromODBC(DOUBLE, list.NewListItem("42.5")))
 	assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", mustFromODBC(GUID, list.NewListItem("550e8400-e29b-41d4-a716-446655440000")))
 	assert.Equal(t, nil, mustFromODBC(GUID, list.NewListItem(nil)))
 	assert.Equal(t, nil, mustFromODBC(GUID, list.NewListItem("")))
 	assert.Equal(t,
-		time.Date(2025, time.September, 16, 10, 20, 30, 123456000, time.UTC).Local(),
+		time.Date(2025, time.September, 16, 10, 20, 30, 123456000, time.UTC),
 		mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636970432)),
 	)
 	assert.Equal(t,
-		time.Date(2025, time.September, 17, 21, 7, 6, 967698000, time.UTC).Local(),
+		time.Date(2025, time.September, 17, 21, 7, 6, 967698000, time.UTC),
 		mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679647833814674)),
 	)
 	assert.Equal(t,
-		time.Date(1025, time.September, 16, 10, 20, 30, 0, time.UTC).Local(),
+		time.Date(1025, time.September, 16, 10, 20, 30, 0, time.UTC),
 		mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(-6947328004811081856)),
 	)
 	assert.Equal(t,
 		"2025-09-16 10:20:30.100000000",
-		toODBC(mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636946976))),
+		codec{}.toODBC(mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636946976))),
 	)
 	assert.Equal(t,
 		"2025-09-16 10:20:30.120000000",
-		toODBC(mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636966976))),
+		codec{}.toODBC(mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636966976))),
 	)
 	assert.Equal(t,
 		"1025-09-16 10:20:30.000000000",
-		toODBC(mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(-6947328004811081856))),
+		codec{}.toODBC(mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(-6947328004811081856))),
 	)
 	assert.Equal(t,
 		"2025-09-16 10:20:30.010000000",
-		toODBC(mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636856976))),
+		codec{}.toODBC(mustFromODBC(TIMESTAMP_POSIX, list.NewListItem(1154679522636856976))),
 	)
 	assert.Equal(t,
 		nil,
@@ -80,6 +92,36 @@ func TestFromODBC(t *testing.T) {
 	)
 }
 
+func TestBinaryFromODBC(t *testing.T) {
+	for _, coltype := range []SQLTYPE{BINARY, VARBINARY} {
+		assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, mustFromODBC(coltype, list.NewListItem([]byte{0xde, 0xad, 0xbe, 0xef})))
+		assert.Equal(t, []byte{0xff, 0x00, 0x80}, mustFromODBC(coltype, list.NewListItem([]byte{0xff, 0x00, 0x80})))
+		assert.Equal(t, []byte{}, mustFromODBC(coltype, list.NewListItem([]byte{0})))
+		assert.Equal(t, nil, mustFromODBC(coltype, list.NewListItem(nil)))
+	}
+	assert.Equal(t, []byte{0}, codec{}.toODBC([]byte{}))
+	assert.Equal(t, []byte{1, 2}, codec{}.toODBC([]byte{1, 2}))
+	assert.Equal(t, int(VARBINARY), parameterType([]byte{1, 2}))
+}
+
+func TestDateTimeFromODBC(t *testing.T) {
+	date := time.Date(2024, time.June, 9, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, date, mustFromODBC(TYPE_DATE, list.NewListItem("2024-06-09")))
+	assert.Equal(t, date, mustFromODBC(DATE_HOROLOG, list.NewListItem(67000)))
+	assert.Equal(t, date, mustFromODBC(DATE_HOROLOG, list.NewListItem("67000")))
+	assert.Equal(t, time.Date(1840, time.December, 31, 0, 0, 0, 0, time.UTC), mustFromODBC(DATE_HOROLOG, list.NewListItem(0)))
+	assert.Equal(t, time.Date(1840, time.December, 30, 0, 0, 0, 0, time.UTC), mustFromODBC(DATE_HOROLOG, list.NewListItem(-1)))
+	assert.Equal(t, nil, mustFromODBC(TYPE_DATE, list.NewListItem(nil)))
+
+	clock := time.Date(0, time.January, 1, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, clock, mustFromODBC(TYPE_TIME, list.NewListItem("01:00:00")))
+	assert.Equal(t, clock, mustFromODBC(TIME_HOROLOG, list.NewListItem(3600)))
+	assert.Equal(t, clock.Add(250*time.Millisecond), mustFromODBC(TYPE_TIME, list.NewListItem("01:00:00.25")))
+	assert.Equal(t, clock.Add(250*time.Millisecond), mustFromODBC(TIME_HOROLOG, list.NewListItem(decimal.RequireFromString("3600.25"))))
+	assert.Equal(t, clock.Add(250*time.Millisecond), mustFromODBC(TIME_HOROLOG, list.NewListItem("3600.25")))
+	assert.Equal(t, nil, mustFromODBC(TIME_HOROLOG, list.NewListItem(nil)))
+}
+
 func TestRowsColumnTypeMetadata(t *testing.T) {
 	rows := &Rows{
 		rs: &ResultSet{
@@ -88,6 +130,8 @@ func TestRowsColumnTypeMetadata(t *testing.T) {
 				{name: "value", column_type: int(DOUBLE), precision: 15, scale: 2, nullable: 1},
 				{name: "created_at", column_type: int(TYPE_TIMESTAMP), nullable: 1},
 				{name: "name", column_type: int(VARCHAR), precision: 64, nullable: 2},
+				{name: "guid", column_type: int(GUID), precision: 36},
+				{name: "amount", column_type: int(NUMERIC), precision: 18, scale: 2},
 			},
 		},
 	}
@@ -114,4 +158,311 @@ func TestRowsColumnTypeMetadata(t *testing.T) {
 	nullable, ok = rows.ColumnTypeNullable(3)
 	assert.False(t, ok)
 	assert.True(t, nullable)
+
+	assert.Equal(t, "GUID", rows.ColumnTypeDatabaseTypeName(4))
+	assert.Equal(t, reflect.TypeOf(iris.UUID{}), rows.ColumnTypeScanType(4))
+
+	// Decimals are returned as exact text, which also scans into
+	// decimal.Decimal and float64
+	assert.Equal(t, "NUMERIC", rows.ColumnTypeDatabaseTypeName(5))
+	assert.Equal(t, reflect.TypeOf(""), rows.ColumnTypeScanType(5))
+}
+
+// otherUUID is a UUID type of another package, with a lowercase Stringer.
+type otherUUID [16]byte
+
+func (u otherUUID) String() string {
+	return strings.ToLower(iris.UUID(u).String())
+}
+
+func TestUUIDParameters(t *testing.T) {
+	u, err := iris.ParseUUID("6f9619ff-8b86-d011-b42d-00c04fc964ff")
+	require.NoError(t, err)
+	for _, value := range []interface{}{u, &u, otherUUID(u)} {
+		checked, err := codec{}.checkValue(value)
+		assert.NoError(t, err)
+		assert.Equal(t, "6F9619FF-8B86-D011-B42D-00C04FC964FF", checked)
+	}
+
+	// Other byte arrays are binary data
+	checked, err := codec{}.checkValue([16]byte(u))
+	assert.NoError(t, err)
+	assert.Equal(t, u[:], checked)
+
+	// GUID columns are returned as text, which UUID scans
+	value, err := codec{}.fromODBC(GUID, list.NewListItem("6F9619FF-8B86-D011-B42D-00C04FC964FF"))
+	require.NoError(t, err)
+	var scanned iris.UUID
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, u, scanned)
+}
+
+func TestInsertTable(t *testing.T) {
+	table, ok := insertTable("INSERT INTO Sample.Person (Name) VALUES (?)")
+	assert.True(t, ok)
+	assert.Equal(t, "Sample.Person", table)
+	table, ok = insertTable("insert or update into person(id, name) values (?, ?)")
+	assert.True(t, ok)
+	assert.Equal(t, "person", table)
+	table, ok = insertTable("  INSERT INTO Sample.\"my table\"\nSELECT * FROM other")
+	assert.True(t, ok)
+	assert.Equal(t, "Sample.\"my table\"", table)
+	_, ok = insertTable("UPDATE person SET name = ?")
+	assert.False(t, ok)
+}
+
+func TestAsInt64(t *testing.T) {
+	for _, v := range []Value{int(42), int32(42), int64(42), float32(42), float64(42), "42"} {
+		key, err := asInt64(v)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), key)
+	}
+	// Keys that are not integers are not truncated
+	for _, v := range []Value{[]byte{1}, 42.5, float32(0.5), 1e19, "4.2"} {
+		_, err := asInt64(v)
+		assert.Error(t, err, "%v", v)
+	}
+}
+
+func TestSetSQLTextChunks(t *testing.T) {
+	sqlText := "SELECT '" + strings.Repeat("é", 20000) + "'"
+	msg := NewMessage(DIRECT_QUERY)
+	assert.NoError(t, msg.SetSQLText(sqlText))
+	var chunks int
+	msg.offset = 0
+	msg.Get(&chunks)
+	assert.Equal(t, 2, chunks)
+	var text string
+	for range chunks {
+		var chunk string
+		msg.Get(&chunk)
+		text += chunk
+	}
+	assert.Equal(t, sqlText, text)
+}
+
+func TestFormatQuery(t *testing.T) {
+	sqlText, count, args := FormatQuery("SELECT * FROM t WHERE a = ? AND b = ?", 1, "x")
+	assert.Equal(t, "SELECT * FROM t WHERE a =  :%qpar(1)  AND b =  :%qpar(2) ", sqlText)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []interface{}{1, "x"}, args)
+
+	// Batches keep the arguments of the further rows
+	sqlText, count, args = FormatQuery("INSERT INTO t (a) VALUES (?)", 1, 2, 3)
+	assert.Equal(t, "INSERT INTO t (a) VALUES ( :%qpar(1) )", sqlText)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	sqlText, count, args = FormatQuery("SELECT * FROM t WHERE id IN (?) AND name = ?", []int{1, 2, 3}, "x")
+	assert.Equal(t, "SELECT * FROM t WHERE id IN ( :%qpar(1) , :%qpar(2) , :%qpar(3) ) AND name =  :%qpar(4) ", sqlText)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, []interface{}{1, 2, 3, "x"}, args)
+
+	sqlText, count, args = FormatQuery("SELECT * FROM t WHERE a = ? AND id in ( ? )", 0, []string{"a"})
+	assert.Equal(t, "SELECT * FROM t WHERE a =  :%qpar(1)  AND id in (  :%qpar(2)  )", sqlText)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []interface{}{0, "a"}, args)
+
+	sqlText, count, args = FormatQuery("SELECT * FROM t WHERE id IN (?)", []int{})
+	assert.Equal(t, "SELECT * FROM t WHERE id IN (NULL)", sqlText)
+	assert.Equal(t, 0, count)
+	assert.Empty(t, args)
+
+	// Only a lone placeholder in IN (...) is expanded, never bytes or vectors
+	for _, query := range []string{"SELECT * FROM t WHERE id IN (?, 1)", "SELECT VECTOR_COSINE(v, ?) FROM t"} {
+		_, _, args = FormatQuery(query, []float64{1, 2})
+		assert.Equal(t, []interface{}{[]float64{1, 2}}, args)
+	}
+	_, _, args = FormatQuery("SELECT * FROM t WHERE b IN (?)", []byte{1, 2})
+	assert.Equal(t, []interface{}{[]byte{1, 2}}, args)
+
+	sqlText, _, args = formatQuery("SELECT * FROM t WHERE id IN (?)", false, []int{1, 2})
+	assert.Equal(t, "SELECT * FROM t WHERE id IN ( :%qpar(1) )", sqlText)
+	assert.Equal(t, []interface{}{[]int{1, 2}}, args)
+}
+
+func TestCheckValueSlices(t *testing.T) {
+	var c Connection
+	value, err := c.CheckValue([]string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, value)
+
+	c.SetSliceExpansion(false)
+	_, err = c.CheckValue([]string{"a", "b"})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	// Slices left unexpanded are rejected before the statement is sent
+	_, err = codec{}.checkValues([]interface{}{[]string{"a"}})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestFeatureOptions(t *testing.T) {
+	for _, tc := range []struct {
+		text    string
+		options FeatureOption
+	}{
+		{"", OptionNone},
+		{"none", OptionNone},
+		{"37", DefaultFeatureOptions},
+		{"fast_select,durable_transactions,redirect_output", DefaultFeatureOptions},
+		{" Durable_Transactions , redirect_output", OptionDurableTransactions | OptionRedirectOutput},
+	} {
+		options, err := ParseFeatureOptions(tc.text)
+		require.NoError(t, err, tc.text)
+		assert.Equal(t, tc.options, options, tc.text)
+	}
+	_, err := ParseFeatureOptions("fast_select,turbo")
+	assert.Error(t, err)
+	for _, text := range []string{"fast_insert", "fast_select,fast_insert", "3"} {
+		_, err = ParseFeatureOptions(text)
+		assert.ErrorIs(t, err, errFastInsertNotSupported, text)
+	}
+	fastInsert := OptionFastSelectAndInsert
+	_, err = ConnectWithOptions("localhost:1972", "USER", "_SYSTEM", "SYS", ConnectOptions{FeatureOptions: &fastInsert})
+	assert.ErrorIs(t, err, errFastInsertNotSupported)
+
+	// Options without feature options request the default ones
+	assert.Equal(t, DefaultFeatureOptions, ConnectOptions{ApplicationName: "svc"}.featureOptions())
+	none := OptionNone
+	assert.Equal(t, OptionNone, ConnectOptions{FeatureOptions: &none}.featureOptions())
+
+	assert.Equal(t, "fast_select,durable_transactions,redirect_output", DefaultFeatureOptions.String())
+	assert.Equal(t, "none", OptionNone.String())
+	assert.Equal(t, "not_nullable,64", (OptionNotNullable | 64).String())
+
+	c := &Connection{featureOptions: OptionDurableTransactions}
+	assert.Equal(t, OptionDurableTransactions, c.FeatureOptions())
+	assert.False(t, c.IsOptionFastSelect())
+	assert.False(t, c.IsOptionFastInsert())
+}
+
+func TestResultSetNext(t *testing.T) {
+	columns := []Column{
+		{name: "id", column_type: int(INTEGER), slot_position: 0},
+		{name: "name", column_type: int(VARCHAR), slot_position: 1},
+	}
+	expected := [][]Value{{int64(1), "one"}, {int64(2), nil}}
+
+	read := func(rs *ResultSet) [][]Value {
+		var rows [][]Value
+		for {
+			row, err := rs.Next()
+			if err == io.EOF {
+				return rows
+			}
+			require.NoError(t, err)
+			rows = append(rows, row)
+		}
+	}
+
+	// Without fast select the items of the rows follow each other, one per
+	// column
+	data, err := list.NewList(1, "one", 2, nil)
+	require.NoError(t, err)
+	rs := &ResultSet{c: &Connection{}, columns: columns, count: len(columns), data: data, sqlCode: 100}
+	assert.Equal(t, expected, read(rs))
+
+	// With fast select each row is a $LIST, whose items columns refer to by
+	// slot position; rows may hold items no column refers to
+	fast := append([]Column(nil), columns...)
+	fast[0].slot_position = 2
+	fast[1].slot_position = 0
+	row1, err := list.NewList("one", "extra", 1)
+	require.NoError(t, err)
+	row2, err := list.NewList(nil, "extra", 2)
+	require.NoError(t, err)
+	data, err = list.NewList(row1, row2)
+	require.NoError(t, err)
+	rs = &ResultSet{
+		c:       &Connection{},
+		sf:      StatementFeature{featureOption: int(OptionFastSelect), maxRowItemCount: 3},
+		columns: fast,
+		count:   len(fast),
+		data:    data,
+		sqlCode: 100,
+	}
+	assert.Equal(t, expected, read(rs))
+
+	// Slots outside of the row are reported
+	fast[0].slot_position = 3
+	rs.offset = 0
+	_, err = rs.Next()
+	assert.Error(t, err)
+}
+
+func TestConnectIdentity(t *testing.T) {
+	user, machine, application := ConnectOptions{
+		OSUser:          "svc",
+		MachineName:     "node-1",
+		ApplicationName: "billing",
+	}.identity()
+	assert.Equal(t, "svc", user)
+	assert.Equal(t, "node-1", machine)
+	assert.Equal(t, "billing", application)
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	user, machine, application = ConnectOptions{}.identity()
+	assert.NotEmpty(t, user)
+	assert.Equal(t, hostname, machine)
+	assert.Equal(t, DefaultApplicationName, application)
+}
+
+func TestGeneratedKeysAfterInsert(t *testing.T) {
+	c, requests := testConnection(t, insertServer)
+
+	// Unless enabled, no keys are fetched
+	res, err := c.DirectUpdate("INSERT INTO Sample.Person (Name) VALUES (?)", "Ann")
+	require.NoError(t, err)
+	require.Len(t, requests(), 1)
+	_, err = res.LastInsertId()
+	assert.ErrorIs(t, err, errKeysNotFetched)
+
+	c.SetGeneratedKeys(true)
+	res, err = c.DirectUpdate("INSERT INTO Sample.Person (Name) VALUES (?)", "Ann")
+	require.NoError(t, err)
+	// The keys are fetched right after the INSERT, not when asked for
+	reqs := requests()[1:]
+	require.Len(t, reqs, 2)
+	assert.Equal(t, GET_AUTO_GENERATED_KEYS, requestType(reqs[1]))
+	assert.Equal(t, reqs[0].header.header[8:12], reqs[1].header.header[8:12])
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), id)
+	assert.Len(t, requests(), 3)
+
+	// Other statements generate no keys
+	res, err = c.DirectUpdate("UPDATE Sample.Person SET Name = ?", "Bob")
+	require.NoError(t, err)
+	_, err = res.LastInsertId()
+	assert.ErrorIs(t, err, errNoGeneratedKeys)
+	assert.Len(t, requests(), 4)
+}
+
+func TestInsertReturning(t *testing.T) {
+	insert, columns, ok := splitReturning("INSERT INTO Sample.Person (Name) VALUES (?) RETURNING ID, %ID, \"Created At\"")
+	assert.True(t, ok)
+	assert.Equal(t, "INSERT INTO Sample.Person (Name) VALUES (?)", insert)
+	assert.Equal(t, []string{"ID", "%ID", `"Created At"`}, columns)
+	_, _, ok = splitReturning("INSERT INTO Sample.Person (Name) VALUES ('RETURNING x')")
+	assert.False(t, ok)
+	_, _, ok = splitReturning("SELECT Name FROM Sample.Person RETURNING ID")
+	assert.False(t, ok)
+
+	c, requests := testConnection(t, insertServer)
+
+	_, err := c.InsertReturning("INSERT INTO Sample.Person (Name) VALUES (?)", []string{"Name; DROP TABLE x"}, "Ann")
+	assert.Error(t, err)
+	assert.Empty(t, requests())
+
+	rs, err := c.Query("INSERT INTO Sample.Person (Name) VALUES (?) RETURNING PersonId, Name", "Ann")
+	require.NoError(t, err)
+	reqs := requests()
+	require.Len(t, reqs, 3)
+	assert.Equal(t, DIRECT_QUERY, requestType(reqs[2]))
+	assert.Equal(t, "SELECT PersonId, Name FROM Sample.Person WHERE PersonId IN ( :%qpar(1) ) ORDER BY PersonId",
+		requestItems(reqs[2])[1])
+	row, err := rs.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []Value{int64(7), "Ann"}, row)
 }
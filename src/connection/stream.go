@@ -0,0 +1,520 @@
+package connection
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/caretdev/go-irisnative/src/iris"
+)
+
+// streamChunkSize is the number of bytes transferred per stream message.
+const streamChunkSize = 32000
+
+var (
+	errStreamClosed   = errors.New("stream is closed")
+	errStreamDetached = errors.New("stream is not attached to a server stream")
+	errStreamWhence   = errors.New("invalid whence")
+	errStreamPosition = errors.New("negative stream position")
+	errStreamReleased = errors.New("stream is no longer readable: its rows are closed")
+)
+
+// Stream is a server-side stream, read and written in chunks. Stream columns
+// (LONGVARCHAR, LONGVARBINARY) are read completely into a string or []byte,
+// unless stream mode is enabled with the "stream_lobs" DSN parameter: values
+// larger than one chunk are then returned as *Stream, read on demand. Stream
+// properties of objects are accessed with OpenStream.
+//
+// The streams of a column share the connection of their rows, and can only
+// be read until the rows are closed, which releases their server handles.
+// ScanText and ScanBytes read stream columns into a string or []byte in
+// either mode.
+//
+// A *Stream can also be used as a scan destination, in which case it wraps
+// whatever value the column holds.
+type Stream struct {
+	c      *Connection
+	handle string
+	binary bool
+	buf    []byte
+	eof    bool
+	closed bool
+	// pos is the offset of the next byte to read or write
+	pos int64
+	// positioned is set once the stream has been sought or written, after
+	// which reads fetch bytes at pos instead of continuing sequentially
+	positioned bool
+	// lease is shared by the copies of a stream read from rows
+	lease *streamLease
+}
+
+// streamLease tracks the server handle of a stream column, which the copies
+// of the stream made by Scan share with the rows it was read from.
+type streamLease struct {
+	// released is set when the rows are closed
+	released bool
+	// closed is set once CLOSE_STREAM has been sent
+	closed bool
+}
+
+func (c *Connection) newStream(handle string, binary bool) *Stream {
+	return &Stream{c: c, handle: handle, binary: binary}
+}
+
+// check returns the error of operations on a closed or released stream.
+func (s *Stream) check() error {
+	if s.closed {
+		return errStreamClosed
+	}
+	if s.lease != nil && s.lease.released {
+		return errStreamReleased
+	}
+	return nil
+}
+
+// StreamHandle returns the stream with the given server handle, as found in
+// stream columns and parameters.
+func (c *Connection) StreamHandle(handle string, binary bool) *Stream {
+	return c.newStream(handle, binary)
+}
+
+// OpenStream opens a %Stream object for reading and writing with OPEN_STREAM.
+func (c *Connection) OpenStream(obj iris.Oref, binary bool) (*Stream, error) {
+	msg := c.newMessage(OPEN_STREAM)
+	msg.Set(obj)
+	msg, err := c.streamRequest(msg)
+	if err != nil {
+		return nil, err
+	}
+	var handle string
+	msg.Get(&handle)
+	stream := c.newStream(handle, binary)
+
+	msg = c.newMessage(STREAM_GET_POSITION)
+	msg.Set(handle)
+	if msg, err = c.streamRequest(msg); err != nil {
+		return nil, err
+	}
+	var position int64
+	msg.Get(&position)
+	if position > 0 {
+		stream.pos = position - 1
+	}
+	return stream, nil
+}
+
+// Handle returns the server handle of the stream.
+func (s *Stream) Handle() string {
+	return s.handle
+}
+
+// Binary reports whether the stream holds binary rather than character data.
+func (s *Stream) Binary() bool {
+	return s.binary
+}
+
+func (s *Stream) Read(p []byte) (n int, err error) {
+	if err = s.check(); err != nil {
+		return 0, err
+	}
+	for len(s.buf) == 0 {
+		if s.eof {
+			return 0, io.EOF
+		}
+		if err = s.fetch(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, s.buf)
+	s.buf = s.buf[n:]
+	s.pos += int64(n)
+	return n, nil
+}
+
+// Write writes p at the current position of the stream with STREAM_SET_BYTES.
+func (s *Stream) Write(p []byte) (n int, err error) {
+	if err = s.check(); err != nil {
+		return 0, err
+	}
+	if s.c == nil {
+		return 0, errStreamDetached
+	}
+	s.buf = nil
+	s.eof = false
+	s.positioned = true
+	for n < len(p) {
+		end := min(n+streamChunkSize, len(p))
+		if err = s.c.writeStreamBytes(s.handle, s.pos, p[n:end]); err != nil {
+			return
+		}
+		s.pos += int64(end - n)
+		n = end
+	}
+	return
+}
+
+// Seek sets the position for the next Read or Write, as io.Seeker.
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+	if s.c == nil {
+		return 0, errStreamDetached
+	}
+	var base int64
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		base = s.pos
+	case io.SeekEnd:
+		size, err := s.Size()
+		if err != nil {
+			return 0, err
+		}
+		base = size
+	default:
+		return 0, errStreamWhence
+	}
+	if base+offset < 0 {
+		return 0, errStreamPosition
+	}
+	s.pos = base + offset
+	s.buf = nil
+	s.eof = false
+	s.positioned = true
+	return s.pos, nil
+}
+
+// Size returns the size of the stream with GET_STREAM_SIZE. For streams
+// made by Scan from a string or []byte it is the size of the value.
+func (s *Stream) Size() (size int64, err error) {
+	if err = s.check(); err != nil {
+		return 0, err
+	}
+	if s.c == nil {
+		// Only read, the bytes read so far and the rest of the value
+		return s.pos + int64(len(s.buf)), nil
+	}
+	msg := s.c.newMessage(GET_STREAM_SIZE)
+	msg.Set(s.handle)
+	if msg, err = s.c.streamRequest(msg); err != nil {
+		return
+	}
+	msg.Get(&size)
+	return
+}
+
+// Truncate cuts the stream to size bytes with STREAM_TRUNCATE.
+func (s *Stream) Truncate(size int64) (err error) {
+	if err = s.check(); err != nil {
+		return err
+	}
+	if s.c == nil {
+		return errStreamDetached
+	}
+	if size < 0 {
+		return errStreamPosition
+	}
+	msg := s.c.newMessage(STREAM_TRUNCATE)
+	msg.Set(s.handle)
+	msg.Set(size)
+	if _, err = s.c.streamRequest(msg); err != nil {
+		return
+	}
+	s.buf = nil
+	s.eof = false
+	s.positioned = true
+	s.pos = min(s.pos, size)
+	return
+}
+
+// fetch reads the next chunk of the stream from the server.
+func (s *Stream) fetch() (err error) {
+	if s.c == nil {
+		s.eof = true
+		return
+	}
+	var msg Message
+	if s.positioned {
+		// Server positions are 1-based
+		msg = s.c.newMessage(STREAM_GET_BYTES)
+		msg.Set(s.handle)
+		msg.Set(s.pos + 1)
+		msg.Set(streamChunkSize)
+	} else {
+		msg = s.c.newMessage(READ_STREAM)
+		msg.header.SetStatementId(s.c.statementId())
+		msg.Set(s.handle)
+		msg.Set(streamChunkSize)
+	}
+	if msg, err = s.c.streamRequest(msg); err != nil {
+		return
+	}
+	var data []byte
+	msg.GetRaw(&data)
+	s.buf = data
+	s.eof = msg.GetStatus() == 100 || len(data) == 0
+	return
+}
+
+// Close releases the server handle of the stream with CLOSE_STREAM.
+func (s *Stream) Close() (err error) {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.buf = nil
+	if s.lease != nil {
+		if s.lease.released || s.lease.closed {
+			return nil
+		}
+		s.lease.closed = true
+	}
+	return s.closeHandle()
+}
+
+// closeHandle releases the server handle of the stream with CLOSE_STREAM.
+func (s *Stream) closeHandle() (err error) {
+	if s.c == nil || s.handle == "" {
+		return nil
+	}
+	msg := s.c.newMessage(CLOSE_STREAM)
+	msg.Set(s.handle)
+	err = s.c.write(msg)
+	if err != nil {
+		return
+	}
+	_, err = s.c.readMessage()
+	return
+}
+
+// release closes the server handle of a stream read from rows, unless the
+// application closed it, when the rows are closed. The stream and its copies
+// cannot be used afterwards.
+func (s *Stream) release() error {
+	if s.lease == nil || s.lease.released {
+		return nil
+	}
+	s.lease.released = true
+	if s.lease.closed {
+		return nil
+	}
+	s.lease.closed = true
+	return s.closeHandle()
+}
+
+// readStream reads a whole stream column with a single READ_STREAM, as a
+// string for character streams and as []byte for binary ones.
+func (c *Connection) readStream(handle string, binary bool) (Value, error) {
+	msg := c.newMessage(READ_STREAM)
+	msg.header.SetStatementId(c.statementId())
+	msg.Set(handle)
+	msg.Set(-1)
+	msg, err := c.streamRequest(msg)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	msg.GetRaw(&data)
+	if binary {
+		return data, nil
+	}
+	return string(data), nil
+}
+
+// value returns the stream as a string or []byte if its first chunk holds all
+// of it, and the stream itself otherwise.
+func (s *Stream) value() (Value, bool, error) {
+	if err := s.fetch(); err != nil {
+		return nil, false, err
+	}
+	if !s.eof {
+		return s, true, nil
+	}
+	if s.binary {
+		return s.buf, false, nil
+	}
+	return string(s.buf), false, nil
+}
+
+// Scan implements sql.Scanner, so *Stream can be used as a scan destination
+// for stream columns in either mode.
+func (s *Stream) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case *Stream:
+		*s = *v
+	case string:
+		*s = Stream{buf: []byte(v), eof: true}
+	case []byte:
+		*s = Stream{buf: append([]byte(nil), v...), binary: true, eof: true}
+	case nil:
+		*s = Stream{eof: true}
+	default:
+		return fmt.Errorf("cannot scan %T into Stream", src)
+	}
+	return nil
+}
+
+// ScanText returns a scan destination reading a stream column, in either
+// mode, into dest. Streams are read completely and closed.
+func ScanText(dest *string) sql.Scanner {
+	return streamScanner(func(data []byte) { *dest = string(data) })
+}
+
+// ScanBytes returns a scan destination reading a stream column, in either
+// mode, into dest. Streams are read completely and closed; NULL values set
+// dest to nil.
+func ScanBytes(dest *[]byte) sql.Scanner {
+	return streamScanner(func(data []byte) { *dest = data })
+}
+
+// streamScanner scans stream columns, passing their content to set.
+type streamScanner func(data []byte)
+
+func (set streamScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case *Stream:
+		data, err := io.ReadAll(v)
+		if cerr := v.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		set(data)
+	case string:
+		set([]byte(v))
+	case []byte:
+		set(append([]byte(nil), v...))
+	case nil:
+		set(nil)
+	default:
+		return fmt.Errorf("cannot scan %T into a stream destination", src)
+	}
+	return nil
+}
+
+// CharacterStream marks a reader parameter as character data. Plain io.Reader
+// parameters are stored as binary streams.
+type CharacterStream struct {
+	io.Reader
+}
+
+// streamHandle is a parameter bound to a stream already stored on the server.
+type streamHandle struct {
+	handle string
+	binary bool
+}
+
+// bindStreams uploads io.Reader parameters, and string and []byte values
+// larger than the stream threshold if one is set, to the server and replaces
+// them by their stream handles. The args slice is not modified.
+func (c *Connection) bindStreams(args []interface{}) ([]interface{}, error) {
+	var bound []interface{}
+	for i, arg := range args {
+		var r io.Reader
+		var binary = true
+		switch v := arg.(type) {
+		case CharacterStream:
+			r, binary = v.Reader, false
+		case io.Reader:
+			r = v
+		case string:
+			if c.streamThreshold > 0 && len(v) > c.streamThreshold {
+				r, binary = strings.NewReader(v), false
+			}
+		case []byte:
+			if c.streamThreshold > 0 && len(v) > c.streamThreshold {
+				r = bytes.NewReader(v)
+			}
+		}
+		if r == nil {
+			continue
+		}
+		if bound == nil {
+			bound = slices.Clone(args)
+		}
+		handle, err := c.storeStream(r, binary)
+		if err != nil {
+			return nil, err
+		}
+		bound[i] = streamHandle{handle, binary}
+	}
+	if bound == nil {
+		return args, nil
+	}
+	return bound, nil
+}
+
+// storeStream uploads the content of r as a new server stream and returns its
+// handle. The first chunk creates the stream with STORE_BINARY_STREAM or
+// STORE_CHARACTER_STREAM, the rest is appended with STREAM_SET_BYTES.
+func (c *Connection) storeStream(r io.Reader, binary bool) (handle string, err error) {
+	messageType := STORE_BINARY_STREAM
+	if !binary {
+		messageType = STORE_CHARACTER_STREAM
+	}
+	chunk := make([]byte, streamChunkSize)
+	var pos int64
+	for first := true; ; first = false {
+		n, rerr := io.ReadFull(r, chunk)
+		if rerr != nil && rerr != io.EOF && rerr != io.ErrUnexpectedEOF {
+			return "", rerr
+		}
+		if first {
+			msg := c.newMessage(messageType)
+			msg.Set(handle)
+			msg.AddRaw(chunk[:n])
+			if msg, err = c.streamRequest(msg); err != nil {
+				return "", err
+			}
+			msg.Get(&handle)
+		} else if n > 0 {
+			if err = c.writeStreamBytes(handle, pos, chunk[:n]); err != nil {
+				return "", err
+			}
+		}
+		pos += int64(n)
+		if rerr != nil {
+			break
+		}
+	}
+	return
+}
+
+// writeStreamBytes writes data to the stream at handle, starting at the
+// 0-based offset pos.
+func (c *Connection) writeStreamBytes(handle string, pos int64, data []byte) (err error) {
+	msg := c.newMessage(STREAM_SET_BYTES)
+	msg.Set(handle)
+	msg.Set(pos + 1)
+	msg.AddRaw(data)
+	_, err = c.streamRequest(msg)
+	return
+}
+
+// streamRequest sends a stream message and reads the reply, turning an error
+// status into an *SQLError.
+func (c *Connection) streamRequest(msg Message) (Message, error) {
+	err := c.write(msg)
+	if err != nil {
+		return msg, err
+	}
+	msg, err = c.readMessage()
+	if err != nil {
+		return msg, err
+	}
+	sqlCode := int16(msg.GetStatus())
+	if sqlCode != 0 && sqlCode != 100 {
+		msgStr, err := c.getErrorInfo(sqlCode)
+		if err != nil {
+			return msg, err
+		}
+		return msg, &SQLError{SQLCode: sqlCode, Message: msgStr}
+	}
+	return msg, nil
+}
@@ -0,0 +1,267 @@
+package connection
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/caretdev/go-irisnative/src/iris"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamScan(t *testing.T) {
+	var s Stream
+	assert.NoError(t, s.Scan("character data"))
+	assert.False(t, s.Binary())
+	data, err := io.ReadAll(&s)
+	assert.NoError(t, err)
+	assert.Equal(t, "character data", string(data))
+	assert.NoError(t, s.Close())
+	_, err = s.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, errStreamClosed)
+
+	assert.NoError(t, s.Scan([]byte{0, 1, 2}))
+	assert.True(t, s.Binary())
+	data, err = io.ReadAll(&s)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 2}, data)
+
+	assert.NoError(t, s.Scan(nil))
+	data, err = io.ReadAll(&s)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	assert.Error(t, s.Scan(42))
+}
+
+func TestParameterType(t *testing.T) {
+	assert.Equal(t, 99, parameterType("value"))
+	assert.Equal(t, int(LONGVARCHAR), parameterType(streamHandle{"1", false}))
+	assert.Equal(t, int(LONGVARBINARY), parameterType(streamHandle{"1", true}))
+	assert.Equal(t, "1", codec{}.toODBC(streamHandle{"1", true}))
+}
+
+func TestBindStreamsInline(t *testing.T) {
+	var c Connection
+	args := []interface{}{"short", []byte{1, 2}, 42, nil}
+	bound, err := c.bindStreams(args)
+	assert.NoError(t, err)
+	assert.Equal(t, args, bound)
+}
+
+func TestStreamColumns(t *testing.T) {
+	c, requests := testConnection(t, streamServer("1", "long2"))
+	rs, err := c.DirectQuery("SELECT Body FROM Sample.Docs")
+	require.NoError(t, err)
+	rows := &Rows{cn: c, rs: rs}
+	dest := make([]driver.Value, 1)
+
+	// Streams are read completely with a single request
+	require.NoError(t, rows.Next(dest))
+	assert.Equal(t, "content of 1", dest[0])
+	require.NoError(t, rows.Next(dest))
+	assert.Equal(t, "start of long2", dest[0])
+	reqs := requests()
+	assert.Equal(t, []MessageType{DIRECT_QUERY, READ_STREAM, READ_STREAM}, requestTypes(reqs))
+	assert.Equal(t, []interface{}{"1", int64(-1)}, requestItems(reqs[1]))
+
+	require.NoError(t, rows.Close())
+	assert.Len(t, requests(), 3)
+}
+
+func TestLazyStreamColumns(t *testing.T) {
+	c, requests := testConnection(t, streamServer("1", "long2", "long3", "long4"))
+	c.SetStreamLOBs(true)
+	rs, err := c.DirectQuery("SELECT Body FROM Sample.Docs")
+	require.NoError(t, err)
+	rows := &Rows{cn: c, rs: rs}
+	dest := make([]driver.Value, 1)
+
+	// Values read with their first chunk are returned as a string
+	require.NoError(t, rows.Next(dest))
+	assert.Equal(t, "content of 1", dest[0])
+	reqs := requests()
+	assert.Equal(t, []MessageType{DIRECT_QUERY, READ_STREAM}, requestTypes(reqs))
+	assert.Equal(t, []interface{}{"1", int64(streamChunkSize)}, requestItems(reqs[1]))
+
+	// Larger ones are read on, through a copy made by Scan
+	require.NoError(t, rows.Next(dest))
+	var second Stream
+	require.NoError(t, second.Scan(dest[0]))
+	data, err := io.ReadAll(&second)
+	require.NoError(t, err)
+	assert.Equal(t, "start of long2content of long2", string(data))
+	require.NoError(t, second.Close())
+	assert.Equal(t, []MessageType{DIRECT_QUERY, READ_STREAM, READ_STREAM, READ_STREAM, CLOSE_STREAM},
+		requestTypes(requests()))
+
+	// Read into a string, which closes the stream
+	require.NoError(t, rows.Next(dest))
+	var text string
+	require.NoError(t, ScanText(&text).Scan(dest[0]))
+	assert.Equal(t, "start of long3content of long3", text)
+	assert.Len(t, requests(), 8)
+
+	// Streams left open are closed with the rows, and cannot be read after
+	require.NoError(t, rows.Next(dest))
+	var fourth Stream
+	require.NoError(t, fourth.Scan(dest[0]))
+	require.NoError(t, rows.Close())
+	reqs = requests()
+	require.Len(t, reqs, 10)
+	assert.Equal(t, CLOSE_STREAM, requestType(reqs[9]))
+	assert.Equal(t, []interface{}{"long4"}, requestItems(reqs[9]))
+	_, err = fourth.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, errStreamReleased)
+	_, err = dest[0].(*Stream).Read(make([]byte, 1))
+	assert.ErrorIs(t, err, errStreamReleased)
+	assert.NoError(t, fourth.Close())
+	assert.Len(t, requests(), 10)
+}
+
+func TestScanStreamValues(t *testing.T) {
+	var (
+		text string
+		data []byte
+	)
+	require.NoError(t, ScanText(&text).Scan("text"))
+	assert.Equal(t, "text", text)
+	require.NoError(t, ScanText(&text).Scan([]byte("bytes")))
+	assert.Equal(t, "bytes", text)
+	require.NoError(t, ScanText(&text).Scan(nil))
+	assert.Equal(t, "", text)
+
+	src := []byte{0, 1, 2}
+	require.NoError(t, ScanBytes(&data).Scan(src))
+	src[0] = 9
+	assert.Equal(t, []byte{0, 1, 2}, data)
+	require.NoError(t, ScanBytes(&data).Scan(&Stream{buf: []byte{3, 4}, binary: true, eof: true}))
+	assert.Equal(t, []byte{3, 4}, data)
+	require.NoError(t, ScanBytes(&data).Scan(nil))
+	assert.Nil(t, data)
+
+	assert.Error(t, ScanText(&text).Scan(42))
+}
+
+func TestBindStreamsThreshold(t *testing.T) {
+	c, requests := testConnection(t, func(req Message) []Message {
+		return reply(0, "42")
+	})
+	long := strings.Repeat("x", 100)
+	args := []interface{}{long, []byte(long)}
+
+	// Values up to the default threshold are sent inline
+	bound, err := c.bindStreams(args)
+	require.NoError(t, err)
+	assert.Equal(t, args, bound)
+	assert.Empty(t, requests())
+
+	// Readers are always uploaded
+	bound, err = c.bindStreams([]interface{}{CharacterStream{strings.NewReader("text")}, strings.NewReader("data")})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{streamHandle{"42", false}, streamHandle{"42", true}}, bound)
+	assert.Equal(t, []MessageType{STORE_CHARACTER_STREAM, STORE_BINARY_STREAM}, requestTypes(requests()))
+
+	huge := strings.Repeat("x", DefaultStreamThreshold+1)
+	bound, err = c.bindStreams([]interface{}{huge})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{streamHandle{"42", false}}, bound)
+	assert.Equal(t, []MessageType{STORE_CHARACTER_STREAM, STREAM_SET_BYTES}, requestTypes(requests()[2:]))
+
+	c.SetStreamThreshold(50)
+	bound, err = c.bindStreams(append(args, "short"))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{streamHandle{"42", false}, streamHandle{"42", true}, "short"}, bound)
+	assert.Len(t, requests(), 6)
+
+	// 0 disables the threshold
+	c.SetStreamThreshold(0)
+	bound, err = c.bindStreams([]interface{}{huge})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{huge}, bound)
+	assert.Len(t, requests(), 6)
+}
+
+func TestStreamWriteSeek(t *testing.T) {
+	store := &streamStore{handle: "7"}
+	c, requests := testConnection(t, store.serve)
+
+	stream, err := c.OpenStream(iris.Oref("1@%Stream.GlobalBinary"), true)
+	require.NoError(t, err)
+	assert.Equal(t, "7", stream.Handle())
+	assert.Equal(t, []MessageType{OPEN_STREAM, STREAM_GET_POSITION}, requestTypes(requests()))
+
+	// Writes are sent in chunks at 1-based positions
+	data := bytes.Repeat([]byte("0123456789"), streamChunkSize/10+1)
+	n, err := stream.Write(data)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	reqs := requests()[2:]
+	require.Len(t, reqs, 2)
+	items, chunk := streamRequestItems(reqs[0], 2)
+	assert.Equal(t, []interface{}{"7", int64(1)}, items)
+	assert.Len(t, chunk, streamChunkSize)
+	items, chunk = streamRequestItems(reqs[1], 2)
+	assert.Equal(t, []interface{}{"7", int64(streamChunkSize + 1)}, items)
+	assert.Len(t, chunk, 10)
+
+	size, err := stream.Size()
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), size)
+
+	// Seek relative to the end, which asks for the size, and read from there
+	pos, err := stream.Seek(-4, io.SeekEnd)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)-4), pos)
+	rest, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "6789", string(rest))
+	items = requestItems(requests()[len(requests())-2])
+	assert.Equal(t, []interface{}{"7", int64(len(data) - 3), int64(streamChunkSize)}, items)
+
+	pos, err = stream.Seek(-2, io.SeekCurrent)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)-2), pos)
+	_, err = stream.Seek(-1, io.SeekStart)
+	assert.ErrorIs(t, err, errStreamPosition)
+	_, err = stream.Seek(0, 42)
+	assert.ErrorIs(t, err, errStreamWhence)
+
+	// Truncate moves the position back within the stream
+	require.NoError(t, stream.Truncate(5))
+	assert.Equal(t, []interface{}{"7", int64(5)}, requestItems(requests()[len(requests())-1]))
+	size, err = stream.Size()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+	_, err = io.WriteString(stream, "!")
+	require.NoError(t, err)
+	assert.Equal(t, "01234!", store.String())
+	assert.Error(t, stream.Truncate(-1))
+
+	require.NoError(t, stream.Close())
+	assert.Equal(t, CLOSE_STREAM, requestType(requests()[len(requests())-1]))
+	_, err = stream.Write([]byte("x"))
+	assert.ErrorIs(t, err, errStreamClosed)
+}
+
+func TestDetachedStreamSize(t *testing.T) {
+	var s Stream
+	require.NoError(t, s.Scan("character data"))
+	size, err := s.Size()
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), size)
+	_, err = s.Read(make([]byte, 4))
+	require.NoError(t, err)
+	size, err = s.Size()
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), size)
+
+	_, err = s.Write([]byte("x"))
+	assert.ErrorIs(t, err, errStreamDetached)
+	_, err = s.Seek(0, io.SeekStart)
+	assert.ErrorIs(t, err, errStreamDetached)
+	assert.ErrorIs(t, s.Truncate(0), errStreamDetached)
+}
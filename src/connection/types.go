@@ -0,0 +1,287 @@
+package connection
+
+import (
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/caretdev/go-irisnative/src/iris"
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/shopspring/decimal"
+)
+
+// typeMapping ties an SQL type to its database type name, the Go type of the
+// values returned for its columns and the decoder producing them. The scan
+// type reported for the columns is the value type, unless scan names a
+// sql.Scanner accepting the values. String columns holding a $LIST are the
+// exception: they return []interface{}, which list.List scans.
+type typeMapping struct {
+	name  string
+	value reflect.Type
+	scan  reflect.Type
+	// decode returns the value of a column. For stream columns it returns
+	// the stream handle, which the rows read into the value.
+	decode func(cd codec, column Column, li list.ListItem) (interface{}, error)
+}
+
+// scanType returns the scan type reported for columns of the mapping.
+func (m typeMapping) scanType() reflect.Type {
+	if m.scan != nil {
+		return m.scan
+	}
+	return m.value
+}
+
+var (
+	stringType  = reflect.TypeOf("")
+	bytesType   = reflect.TypeOf([]byte{})
+	boolType    = reflect.TypeOf(false)
+	int64Type   = reflect.TypeOf(int64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// typeMappings is the mapping of the SQL types the driver knows. Columns of
+// other types are returned as strings.
+var typeMappings = map[SQLTYPE]typeMapping{
+	GUID:            {"GUID", stringType, reflect.TypeOf(iris.UUID{}), decodeText},
+	WLONGVARCHAR:    {"WLONGVARCHAR", stringType, nil, decodeString},
+	WVARCHAR:        {"WVARCHAR", stringType, nil, decodeString},
+	WCHAR:           {"WCHAR", stringType, nil, decodeString},
+	BIT:             {"BIT", boolType, nil, decodeBool},
+	TINYINT:         {"TINYINT", int64Type, nil, decodeInt64},
+	BIGINT:          {"BIGINT", int64Type, nil, decodeInt64},
+	LONGVARBINARY:   {"LONGVARBINARY", bytesType, nil, decodeText},
+	VARBINARY:       {"VARBINARY", bytesType, nil, decodeBinary},
+	BINARY:          {"BINARY", bytesType, nil, decodeBinary},
+	LONGVARCHAR:     {"LONGVARCHAR", stringType, nil, decodeText},
+	CHAR:            {"CHAR", stringType, nil, decodeString},
+	NUMERIC:         {"NUMERIC", stringType, nil, decodeDecimal},
+	DECIMAL:         {"DECIMAL", stringType, nil, decodeDecimal},
+	INTEGER:         {"INTEGER", int64Type, nil, decodeInt64},
+	SMALLINT:        {"SMALLINT", int64Type, nil, decodeInt64},
+	FLOAT:           {"FLOAT", float64Type, nil, decodeFloat64},
+	REAL:            {"REAL", float64Type, nil, decodeFloat64},
+	DOUBLE:          {"DOUBLE", float64Type, nil, decodeFloat64},
+	DATE:            {"DATE", timeType, nil, decodeDate},
+	TIME:            {"TIME", timeType, nil, decodeTime},
+	TIMESTAMP:       {"TIMESTAMP", timeType, nil, decodeTimestamp},
+	VARCHAR:         {"VARCHAR", stringType, nil, decodeVarchar},
+	TYPE_DATE:       {"DATE", timeType, nil, decodeDate},
+	TYPE_TIME:       {"TIME", timeType, nil, decodeTime},
+	TYPE_TIMESTAMP:  {"TIMESTAMP", timeType, nil, decodeTimestamp},
+	DATE_HOROLOG:    {"DATE", timeType, nil, decodeDate},
+	TIME_HOROLOG:    {"TIME", timeType, nil, decodeTime},
+	TIMESTAMP_POSIX: {"TIMESTAMP", timeType, nil, decodePosix},
+	VECTOR:          {"VECTOR", reflect.TypeOf([]float64{}), nil, decodeVector},
+}
+
+// legacyTypeMappings replace typeMappings with legacy types: int for the
+// integer types narrower than BIGINT and float32 for FLOAT and REAL.
+var legacyTypeMappings = map[SQLTYPE]typeMapping{
+	TINYINT:  {"TINYINT", reflect.TypeOf(0), nil, decodeInt},
+	SMALLINT: {"SMALLINT", reflect.TypeOf(0), nil, decodeInt},
+	INTEGER:  {"INTEGER", reflect.TypeOf(0), nil, decodeInt},
+	FLOAT:    {"FLOAT", reflect.TypeOf(float32(0)), nil, decodeFloat32},
+	REAL:     {"REAL", reflect.TypeOf(float32(0)), nil, decodeFloat32},
+}
+
+var defaultTypeMapping = typeMapping{"", stringType, nil, decodeString}
+
+// typeMapping returns the mapping of coltype.
+func (cd codec) typeMapping(coltype SQLTYPE) typeMapping {
+	if cd.legacyTypes {
+		if m, ok := legacyTypeMappings[coltype]; ok {
+			return m
+		}
+	}
+	if m, ok := typeMappings[coltype]; ok {
+		return m
+	}
+	return defaultTypeMapping
+}
+
+func databaseTypeName(colType SQLTYPE) string {
+	return codec{}.typeMapping(colType).name
+}
+
+func decodeString(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	if values, ok, err := cd.listFromODBC(li); ok {
+		return values, err
+	}
+	var value string
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// listFromODBC decodes string values holding a $LIST, as stored in %List
+// properties or built with $LISTBUILD, into []interface{}, when list columns
+// are enabled. Only values with the control characters that encode the items
+// are taken for lists.
+func (cd codec) listFromODBC(li list.ListItem) ([]interface{}, bool, error) {
+	if !cd.listColumns || li.Type() != list.LISTITEM_STRING {
+		return nil, false, nil
+	}
+	var raw []byte
+	if err := li.Get(&raw); err != nil {
+		return nil, true, err
+	}
+	if !slices.ContainsFunc(raw, func(b byte) bool { return b < 0x20 }) {
+		return nil, false, nil
+	}
+	if l := list.List(raw); l.Valid() {
+		values, err := l.Values()
+		return values, true, err
+	}
+	return nil, false, nil
+}
+
+// decodeText decodes strings that never hold lists, such as GUIDs and stream
+// handles, returning nil for empty values.
+func decodeText(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	if li.DataLength() == 0 {
+		return nil, nil
+	}
+	var value string
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// decodeVarchar decodes strings, with $CHAR(0) standing for the empty string.
+func decodeVarchar(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	if li.DataLength() == 0 {
+		return nil, nil
+	}
+	if values, ok, err := cd.listFromODBC(li); ok {
+		return values, err
+	}
+	var value string
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	if value == "\x00" {
+		value = ""
+	}
+	return value, nil
+}
+
+func decodeBool(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	var value bool
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeInt64(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	var value int64
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeInt(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	var value int
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeFloat64(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	var value float64
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeFloat32(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	var value float32
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// decodeDecimal returns exact decimal text, which scans into decimal.Decimal,
+// string and float64 alike; database/sql cannot convert decimal.Decimal
+// values into strings.
+func decodeDecimal(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	var value decimal.Decimal
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return value.String(), nil
+}
+
+func decodeBinary(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	var value []byte
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	if len(value) == 1 && value[0] == 0 {
+		// Empty value, stored as $CHAR(0) like empty strings
+		return []byte{}, nil
+	}
+	// Copy, the item refers to the message buffer
+	return append([]byte(nil), value...), nil
+}
+
+func decodeDate(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	t, err := dateFromODBC(li, cd.serverLocation())
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func decodeTime(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	t, err := timeFromODBC(li, cd.serverLocation())
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func decodeTimestamp(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	var strval string
+	if err := li.Get(&strval); err != nil {
+		return nil, err
+	}
+	t, err := time.ParseInLocation(timeLayoutShort, strval, cd.serverLocation())
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// decodePosix decodes %PosixTime values, also accepting ODBC timestamps.
+func decodePosix(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	if li.DataLength() == 0 {
+		return nil, nil
+	}
+	if li.Type() == list.LISTITEM_STRING {
+		var strval string
+		if err := li.Get(&strval); err != nil {
+			return nil, err
+		}
+		if t, err := time.ParseInLocation(timeLayout, strval, cd.serverLocation()); err == nil {
+			return t, nil
+		}
+	}
+	var value int64
+	if err := li.Get(&value); err != nil {
+		return nil, err
+	}
+	return cd.fromPosix(value), nil
+}
+
+func decodeVector(cd codec, column Column, li list.ListItem) (interface{}, error) {
+	return vectorFromODBC(li, column.vectorType())
+}
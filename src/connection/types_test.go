@@ -0,0 +1,158 @@
+package connection
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// typeSamples holds a value as sent by the server for each mapped type.
+var typeSamples = map[SQLTYPE]interface{}{
+	GUID:            "6F9619FF-8B86-D011-B42D-00C04FC964FF",
+	WLONGVARCHAR:    "text",
+	WVARCHAR:        "text",
+	WCHAR:           "text",
+	BIT:             true,
+	TINYINT:         1,
+	BIGINT:          int64(1) << 40,
+	VARBINARY:       []byte{1, 2},
+	BINARY:          []byte{1, 2},
+	CHAR:            "text",
+	NUMERIC:         "1.50",
+	DECIMAL:         "1.50",
+	INTEGER:         42,
+	SMALLINT:        -3,
+	FLOAT:           1.25,
+	REAL:            1.25,
+	DOUBLE:          1.25,
+	DATE:            "2024-06-09",
+	TIME:            "13:45:30",
+	TIMESTAMP:       "2024-06-09 13:45:30",
+	VARCHAR:         "text",
+	TYPE_DATE:       67000,
+	TYPE_TIME:       3600,
+	TYPE_TIMESTAMP:  "2024-06-09 13:45:30.5",
+	DATE_HOROLOG:    67000,
+	TIME_HOROLOG:    3600,
+	TIMESTAMP_POSIX: 1154679522636970432,
+	VECTOR:          "1,2,3",
+}
+
+func TestTypeMappings(t *testing.T) {
+	scannerType := reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	for _, legacy := range []bool{false, true} {
+		cd := codec{legacyTypes: legacy}
+		for coltype, sample := range typeSamples {
+			m := cd.typeMapping(coltype)
+			require.NotEmpty(t, m.name, "%d", coltype)
+			value, err := cd.fromODBC(coltype, list.NewListItem(sample))
+			require.NoError(t, err, "%s", m.name)
+			assert.Equal(t, m.value, reflect.TypeOf(value), "%s", m.name)
+			if m.scan != nil {
+				assert.True(t, reflect.PointerTo(m.scan).Implements(scannerType), "%s", m.name)
+			}
+		}
+	}
+
+	// Stream columns are named too; their values are read by the rows
+	for _, coltype := range []SQLTYPE{LONGVARCHAR, LONGVARBINARY} {
+		assert.NotEmpty(t, databaseTypeName(coltype))
+	}
+	assert.Equal(t, "", databaseTypeName(SQLTYPE(999)))
+	value, err := codec{}.fromODBC(SQLTYPE(999), list.NewListItem(5))
+	assert.NoError(t, err)
+	assert.Equal(t, "5", value)
+}
+
+func TestLegacyTypes(t *testing.T) {
+	value, err := codec{}.fromODBC(INTEGER, list.NewListItem(42))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), value)
+	value, err = codec{}.fromODBC(REAL, list.NewListItem(1.5))
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, value)
+
+	legacy := codec{legacyTypes: true}
+	value, err = legacy.fromODBC(INTEGER, list.NewListItem(42))
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+	value, err = legacy.fromODBC(REAL, list.NewListItem(1.5))
+	assert.NoError(t, err)
+	assert.Equal(t, float32(1.5), value)
+	value, err = legacy.fromODBC(BIGINT, list.NewListItem(42))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), value)
+
+	c := &Connection{}
+	c.SetLegacyTypes(true)
+	rows := &Rows{cn: c, rs: &ResultSet{columns: []Column{{column_type: int(SMALLINT)}, {column_type: int(FLOAT)}}}}
+	assert.Equal(t, reflect.TypeOf(0), rows.ColumnTypeScanType(0))
+	assert.Equal(t, reflect.TypeOf(float32(0)), rows.ColumnTypeScanType(1))
+	rows.cn = nil
+	assert.Equal(t, reflect.TypeOf(int64(0)), rows.ColumnTypeScanType(0))
+	assert.Equal(t, reflect.TypeOf(float64(0)), rows.ColumnTypeScanType(1))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		cd := codec{legacyTypes: legacy}
+		for _, coltype := range []SQLTYPE{BIT, TINYINT, SMALLINT, INTEGER, BIGINT, FLOAT, REAL, DOUBLE,
+			NUMERIC, TIMESTAMP, TIMESTAMP_POSIX, DATE, TIME} {
+			value, err := cd.fromODBC(coltype, list.NewListItem("abc"))
+			assert.Error(t, err, "%s legacy=%v", databaseTypeName(coltype), legacy)
+			assert.Nil(t, value, "%s legacy=%v", databaseTypeName(coltype), legacy)
+		}
+	}
+}
+
+func TestListColumns(t *testing.T) {
+	l, err := list.NewList("a", 1, nil, 2.5)
+	require.NoError(t, err)
+	cd := codec{listColumns: true}
+	for _, coltype := range []SQLTYPE{VARCHAR, CHAR, WVARCHAR, SQLTYPE(999)} {
+		value, err := cd.fromODBC(coltype, list.NewListItem(l))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", int64(1), nil, 2.5}, value)
+
+		// Unless enabled, strings are strings whatever they hold
+		value, err = codec{}.fromODBC(coltype, list.NewListItem(l))
+		require.NoError(t, err)
+		assert.Equal(t, string(l), value)
+		value, err = codec{}.fromODBC(coltype, list.NewListItem("\x01"))
+		require.NoError(t, err)
+		assert.Equal(t, "\x01", value)
+	}
+
+	// Text, binary data and stream handles are left alone when enabled too
+	for _, tc := range []struct {
+		coltype  SQLTYPE
+		value    interface{}
+		expected interface{}
+	}{
+		{VARCHAR, "\x00", ""},
+		{VARCHAR, "line 1\nline 2", "line 1\nline 2"},
+		{VARCHAR, "\x03\x01", "\x03\x01"},
+		{VARBINARY, []byte(l), []byte(l)},
+		{LONGVARCHAR, string(l), string(l)},
+	} {
+		value, err := cd.fromODBC(tc.coltype, list.NewListItem(tc.value))
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, value)
+	}
+
+	var scanned list.List
+	value, err := codec{}.fromODBC(VARCHAR, list.NewListItem(l))
+	require.NoError(t, err)
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, l, scanned)
+
+	// Lists are sent back as binary data
+	checked, err := codec{}.checkValue(l)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(l), checked)
+	assert.Equal(t, int(VARBINARY), parameterType(checked))
+}
@@ -0,0 +1,301 @@
+package connection
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/shopspring/decimal"
+)
+
+// VectorType is the element type of an IRIS VECTOR.
+type VectorType string
+
+const (
+	VectorInteger VectorType = "INTEGER"
+	VectorDouble  VectorType = "DOUBLE"
+	VectorFloat   VectorType = "FLOAT"
+	VectorDecimal VectorType = "DECIMAL"
+	VectorString  VectorType = "STRING"
+	VectorTime    VectorType = "TIME"
+)
+
+// vectorTypes maps the scale reported for VECTOR columns to their element
+// type; the precision holds the dimension.
+var vectorTypes = map[int]VectorType{
+	1: VectorInteger,
+	2: VectorDouble,
+	3: VectorDecimal,
+	4: VectorString,
+	5: VectorTime,
+	6: VectorFloat,
+}
+
+// Vector is an IRIS VECTOR value. Values holds the elements as []int64 for
+// INTEGER vectors, []float64 for DOUBLE, []float32 for FLOAT,
+// []decimal.Decimal for DECIMAL and []string for STRING and TIME vectors.
+//
+// Vector columns are returned as the slice of their element type, which Scan
+// accepts; []float32, []float64, []int32 and []int64 parameters are sent as
+// vectors like Vector values.
+type Vector struct {
+	Type   VectorType
+	Values interface{}
+}
+
+// NewVector returns the vector of the elements of values, a slice of one of
+// the element types of Vector or []int32.
+func NewVector(values interface{}) (Vector, error) {
+	switch v := values.(type) {
+	case Vector:
+		return v, nil
+	case []int32:
+		ints := make([]int64, len(v))
+		for i, n := range v {
+			ints[i] = int64(n)
+		}
+		return Vector{VectorInteger, ints}, nil
+	case []int64:
+		return Vector{VectorInteger, v}, nil
+	case []float64:
+		return Vector{VectorDouble, v}, nil
+	case []float32:
+		return Vector{VectorFloat, v}, nil
+	case []decimal.Decimal:
+		return Vector{VectorDecimal, v}, nil
+	case []string:
+		return Vector{VectorString, v}, nil
+	}
+	return Vector{}, fmt.Errorf("cannot make a vector of %T", values)
+}
+
+// Len returns the dimension of the vector.
+func (v Vector) Len() int {
+	if v.Values == nil {
+		return 0
+	}
+	return reflect.ValueOf(v.Values).Len()
+}
+
+// String returns the elements separated by commas, the text form TO_VECTOR
+// accepts.
+func (v Vector) String() string {
+	var b strings.Builder
+	switch values := v.Values.(type) {
+	case []int64:
+		for i, n := range values {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.FormatInt(n, 10))
+		}
+	case []float64:
+		for i, f := range values {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	case []float32:
+		for i, f := range values {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+		}
+	case []decimal.Decimal:
+		for i, d := range values {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(d.String())
+		}
+	case []string:
+		b.WriteString(strings.Join(values, ","))
+	}
+	return b.String()
+}
+
+// Float64s returns the elements of a numeric vector as float64.
+func (v Vector) Float64s() ([]float64, error) {
+	switch values := v.Values.(type) {
+	case nil:
+		return nil, nil
+	case []float64:
+		return values, nil
+	case []float32:
+		floats := make([]float64, len(values))
+		for i, f := range values {
+			floats[i] = float64(f)
+		}
+		return floats, nil
+	case []int64:
+		floats := make([]float64, len(values))
+		for i, n := range values {
+			floats[i] = float64(n)
+		}
+		return floats, nil
+	case []decimal.Decimal:
+		floats := make([]float64, len(values))
+		for i, d := range values {
+			floats[i] = d.InexactFloat64()
+		}
+		return floats, nil
+	}
+	return nil, fmt.Errorf("%s vector is not numeric", v.Type)
+}
+
+// Scan implements sql.Scanner for vector columns. Text is parsed as a DOUBLE
+// vector.
+func (v *Vector) Scan(src interface{}) (err error) {
+	switch s := src.(type) {
+	case nil:
+		*v = Vector{}
+		return nil
+	case string:
+		*v, err = parseVector(s, VectorDouble)
+		return
+	case []byte:
+		*v, err = parseVector(string(s), VectorDouble)
+		return
+	}
+	*v, err = NewVector(src)
+	return
+}
+
+// vectorType returns the element type of a VECTOR column, empty when the
+// server reports one the driver does not know.
+func (c Column) vectorType() VectorType {
+	return vectorTypes[c.scale]
+}
+
+// vectorScanType returns the type vector columns of the element type are
+// returned as.
+func vectorScanType(elemType VectorType) reflect.Type {
+	switch elemType {
+	case VectorInteger:
+		return reflect.TypeOf([]int64{})
+	case VectorFloat:
+		return reflect.TypeOf([]float32{})
+	case VectorDecimal:
+		return reflect.TypeOf([]decimal.Decimal{})
+	case VectorString, VectorTime:
+		return reflect.TypeOf([]string{})
+	default:
+		return reflect.TypeOf([]float64{})
+	}
+}
+
+// vectorFromODBC decodes a VECTOR value, sent either as its text form or as
+// a $LIST of the elements, into the slice of the element type. Vectors of
+// unknown element type are decoded as DOUBLE.
+func vectorFromODBC(li list.ListItem, elemType VectorType) (interface{}, error) {
+	if elemType == "" {
+		elemType = VectorDouble
+	}
+	var (
+		v   Vector
+		err error
+	)
+	if li.Type() == list.LISTITEM_STRING {
+		var raw []byte
+		if err = li.Get(&raw); err != nil {
+			return nil, err
+		}
+		if l := list.List(raw); len(l) > 0 && l.Valid() {
+			if v, err = listVector(l, elemType); err != nil {
+				return nil, err
+			}
+			return v.Values, nil
+		}
+	}
+	var text string
+	if err = li.Get(&text); err != nil {
+		return nil, err
+	}
+	if v, err = parseVector(text, elemType); err != nil {
+		return nil, err
+	}
+	return v.Values, nil
+}
+
+// parseVector parses the comma separated elements of a vector, optionally
+// enclosed in brackets.
+func parseVector(text string, elemType VectorType) (Vector, error) {
+	text = strings.TrimSpace(text)
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "["), "]")
+	var elems []string
+	if strings.TrimSpace(text) != "" {
+		elems = strings.Split(text, ",")
+	}
+	v := Vector{Type: elemType}
+	var err error
+	switch elemType {
+	case VectorInteger:
+		v.Values, err = parseElements(elems, func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		})
+	case VectorFloat:
+		v.Values, err = parseElements(elems, func(s string) (float32, error) {
+			f, err := strconv.ParseFloat(s, 32)
+			return float32(f), err
+		})
+	case VectorDecimal:
+		v.Values, err = parseElements(elems, decimal.NewFromString)
+	case VectorString, VectorTime:
+		v.Values, err = parseElements(elems, func(s string) (string, error) {
+			return s, nil
+		})
+	default:
+		v.Values, err = parseElements(elems, func(s string) (float64, error) {
+			return strconv.ParseFloat(s, 64)
+		})
+	}
+	return v, err
+}
+
+func parseElements[T any](elems []string, parse func(string) (T, error)) ([]T, error) {
+	values := make([]T, len(elems))
+	for i, elem := range elems {
+		value, err := parse(strings.TrimSpace(elem))
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector element %d: %w", i+1, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// listVector decodes a vector sent as a $LIST of its elements.
+func listVector(l list.List, elemType VectorType) (Vector, error) {
+	v := Vector{Type: elemType}
+	var err error
+	switch elemType {
+	case VectorInteger:
+		v.Values, err = listElements[int64](l)
+	case VectorFloat:
+		v.Values, err = listElements[float32](l)
+	case VectorDecimal:
+		v.Values, err = listElements[decimal.Decimal](l)
+	case VectorString, VectorTime:
+		v.Values, err = listElements[string](l)
+	default:
+		v.Values, err = listElements[float64](l)
+	}
+	return v, err
+}
+
+func listElements[T any](l list.List) ([]T, error) {
+	values := []T{}
+	it := l.Iter()
+	for it.Next() {
+		var value T
+		if err := it.Item().Get(&value); err != nil {
+			return nil, fmt.Errorf("invalid vector element %d: %w", len(values)+1, err)
+		}
+		values = append(values, value)
+	}
+	return values, it.Err()
+}
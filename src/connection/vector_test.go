@@ -0,0 +1,124 @@
+package connection
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectorParameters(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{[]float64{0.1, -2, 3e-10}, "0.1,-2,3e-10"},
+		{[]float32{0.1, 2.5}, "0.1,2.5"},
+		{[]int32{1, -2, 3}, "1,-2,3"},
+		{[]int64{1 << 40}, "1099511627776"},
+		{Vector{VectorDecimal, []decimal.Decimal{decimal.RequireFromString("1.10")}}, "1.1"},
+		{Vector{VectorString, []string{"a", "b"}}, "a,b"},
+		{[]float64{}, ""},
+	} {
+		value, err := codec{}.checkValue(tc.value)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, codec{}.toODBC(value))
+		assert.Equal(t, int(VECTOR), parameterType(value))
+	}
+}
+
+func TestVectorFromODBC(t *testing.T) {
+	value, err := codec{}.fromODBC(VECTOR, list.NewListItem("0.5,1,-2"))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 1, -2}, value)
+
+	for _, tc := range []struct {
+		column   Column
+		item     list.ListItem
+		expected interface{}
+	}{
+		{Column{scale: 1, precision: 3}, list.NewListItem("1,2,3"), []int64{1, 2, 3}},
+		{Column{scale: 2}, list.NewListItem("[0.25, 4]"), []float64{0.25, 4}},
+		{Column{scale: 6}, list.NewListItem("0.5"), []float32{0.5}},
+		{Column{scale: 3}, list.NewListItem("1.10,2"), []decimal.Decimal{decimal.RequireFromString("1.10"), decimal.RequireFromString("2")}},
+		{Column{scale: 4}, list.NewListItem("a,b"), []string{"a", "b"}},
+		{Column{scale: 1}, list.NewListItem(list.List(mustVectorList(7, 8))), []int64{7, 8}},
+		{Column{scale: 2}, list.NewListItem(list.List(mustVectorList(1.5, 2))), []float64{1.5, 2}},
+		{Column{scale: 2}, list.NewListItem("[]"), []float64{}},
+	} {
+		tc.column.column_type = int(VECTOR)
+		value, err := codec{}.fromColumn(tc.column, tc.item)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, value)
+	}
+
+	_, err = codec{}.fromColumn(Column{column_type: int(VECTOR), scale: 1}, list.NewListItem("1,x"))
+	assert.Error(t, err)
+	value, err = codec{}.fromODBC(VECTOR, list.NewListItem(nil))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func mustVectorList(values ...interface{}) list.List {
+	l, err := list.NewList(values...)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func TestVectorScan(t *testing.T) {
+	var v Vector
+	require.NoError(t, v.Scan([]float32{1, 2}))
+	assert.Equal(t, Vector{VectorFloat, []float32{1, 2}}, v)
+	assert.Equal(t, 2, v.Len())
+	floats, err := v.Float64s()
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, floats)
+
+	require.NoError(t, v.Scan("1.5,2.5"))
+	assert.Equal(t, Vector{VectorDouble, []float64{1.5, 2.5}}, v)
+	require.NoError(t, v.Scan([]int32{4}))
+	assert.Equal(t, Vector{VectorInteger, []int64{4}}, v)
+	require.NoError(t, v.Scan(nil))
+	assert.Equal(t, 0, v.Len())
+	assert.Error(t, v.Scan(42))
+
+	var _ sql.Scanner = &v
+	_, err = Vector{VectorString, []string{"a"}}.Float64s()
+	assert.Error(t, err)
+}
+
+func TestVectorColumnMetadata(t *testing.T) {
+	rows := &Rows{
+		rs: &ResultSet{
+			columns: []Column{
+				{name: "embedding", column_type: int(VECTOR), precision: 384, scale: 2},
+				{name: "ids", column_type: int(VECTOR), precision: 3, scale: 1},
+				{name: "name", column_type: int(VARCHAR), precision: 64},
+			},
+		},
+	}
+	assert.Equal(t, "VECTOR", rows.ColumnTypeDatabaseTypeName(0))
+	assert.Equal(t, reflect.TypeOf([]float64{}), rows.ColumnTypeScanType(0))
+	length, ok := rows.ColumnTypeLength(0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(384), length)
+	elemType, dimension, ok := rows.ColumnTypeVector(0)
+	assert.True(t, ok)
+	assert.Equal(t, VectorDouble, elemType)
+	assert.Equal(t, 384, dimension)
+
+	assert.Equal(t, reflect.TypeOf([]int64{}), rows.ColumnTypeScanType(1))
+	elemType, dimension, ok = rows.ColumnTypeVector(1)
+	assert.True(t, ok)
+	assert.Equal(t, VectorInteger, elemType)
+	assert.Equal(t, 3, dimension)
+
+	_, _, ok = rows.ColumnTypeVector(2)
+	assert.False(t, ok)
+}
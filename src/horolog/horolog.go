@@ -0,0 +1,165 @@
+// Package horolog converts between Go time.Time values and the date and time
+// representations used by InterSystems IRIS: $HOROLOG, $ZTIMESTAMP,
+// %PosixTime and ODBC date/time strings.
+//
+// $HOROLOG values ("67000,3600") count days since December 31, 1840 and
+// seconds since midnight in local wall-clock time, so conversions take the
+// *time.Location the value is expressed in. $ZTIMESTAMP has the same layout
+// with fractional seconds and is always UTC.
+package horolog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Epoch is day 0 of $HOROLOG, December 31, 1840.
+var Epoch = time.Date(1840, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+const secondsPerDay = 24 * 60 * 60
+
+const (
+	// ODBCDate is the layout of ODBC date strings.
+	ODBCDate = "2006-01-02"
+	// ODBCTime is the layout of ODBC time strings.
+	ODBCTime = "15:04:05"
+	// ODBCTimestamp is the layout of ODBC timestamp strings. Fractional
+	// seconds are accepted when parsing.
+	ODBCTimestamp = "2006-01-02 15:04:05"
+)
+
+var ErrInvalidHorolog = errors.New("horolog: invalid $HOROLOG value")
+
+// Days returns the $HOROLOG day number of the date of t in its location.
+func Days(t time.Time) int {
+	y, m, d := t.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return int((date.Unix() - Epoch.Unix()) / secondsPerDay)
+}
+
+// Seconds returns the number of seconds since midnight of t in its location,
+// including the fractional part.
+func Seconds(t time.Time) float64 {
+	return float64(t.Hour()*3600+t.Minute()*60+t.Second()) + float64(t.Nanosecond())/1e9
+}
+
+// DateOf returns midnight in loc of the $HOROLOG day number days.
+func DateOf(days int, loc *time.Location) time.Time {
+	return time.Date(1840, time.December, 31+days, 0, 0, 0, 0, loc)
+}
+
+// TimeOf returns the time of day seconds after midnight, on January 1 of
+// year 0 in loc, like time.Parse does for layouts without a date.
+func TimeOf(seconds float64, loc *time.Location) time.Time {
+	return time.Date(0, time.January, 1, 0, 0, 0, nanoseconds(seconds), loc)
+}
+
+// nanoseconds converts seconds to nanoseconds, rounded to microseconds, the
+// highest precision IRIS keeps.
+func nanoseconds(seconds float64) int {
+	return int(seconds*1e6+0.5) * 1000
+}
+
+// Time returns the time of the $HOROLOG day and seconds in loc. The seconds
+// are wall-clock time, so on days with a daylight saving transition they are
+// not the time elapsed since midnight.
+func Time(days int, seconds float64, loc *time.Location) time.Time {
+	return time.Date(1840, time.December, 31+days, 0, 0, 0, nanoseconds(seconds), loc)
+}
+
+// Parse parses a $HOROLOG string, "days,seconds", as a time in loc. The
+// seconds may be omitted and may have a fractional part.
+func Parse(h string, loc *time.Location) (time.Time, error) {
+	days, seconds, err := split(h)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return Time(days, seconds, loc), nil
+}
+
+// Format returns the $HOROLOG string of t in its location, with whole
+// seconds.
+func Format(t time.Time) string {
+	return fmt.Sprintf("%d,%d", Days(t), int(Seconds(t)))
+}
+
+// ParseZTimestamp parses a $ZTIMESTAMP string, "days,seconds.fraction" in
+// UTC, and returns the time in loc.
+func ParseZTimestamp(h string, loc *time.Location) (time.Time, error) {
+	t, err := Parse(h, time.UTC)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(loc), nil
+}
+
+// FormatZTimestamp returns the $ZTIMESTAMP string of t, with milliseconds as
+// IRIS does.
+func FormatZTimestamp(t time.Time) string {
+	t = t.UTC()
+	seconds := strconv.FormatFloat(float64(t.Hour()*3600+t.Minute()*60+t.Second())+float64(t.Nanosecond()/1e6)/1e3, 'f', -1, 64)
+	return fmt.Sprintf("%d,%s", Days(t), seconds)
+}
+
+func split(h string) (days int, seconds float64, err error) {
+	dayPart, secondPart, hasSeconds := strings.Cut(strings.TrimSpace(h), ",")
+	if days, err = strconv.Atoi(dayPart); err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidHorolog, h)
+	}
+	if hasSeconds && secondPart != "" {
+		seconds, err = strconv.ParseFloat(secondPart, 64)
+		if err != nil || seconds < 0 || seconds >= secondsPerDay {
+			return 0, 0, fmt.Errorf("%w: %q", ErrInvalidHorolog, h)
+		}
+	}
+	return days, seconds, nil
+}
+
+// %PosixTime stores microseconds since the Unix epoch with the top bits
+// rearranged so that all valid values sort as integers.
+const (
+	posixPositive = 0x1000000000000000
+	posixNegative = 0x6000000000000000
+)
+
+// FromPosix decodes a %PosixTime value and returns the time in loc.
+func FromPosix(value int64, loc *time.Location) time.Time {
+	if value > 0 {
+		value ^= posixPositive
+	} else {
+		value |= posixNegative
+	}
+	return time.UnixMicro(value).In(loc)
+}
+
+// ToPosix encodes t as a %PosixTime value, truncated to microseconds.
+func ToPosix(t time.Time) int64 {
+	value := t.UnixMicro()
+	if value >= 0 {
+		return value ^ posixPositive
+	}
+	return value &^ posixNegative
+}
+
+// ParseODBC parses an ODBC timestamp, date or time string as a time in loc.
+// Times without a date are on January 1 of year 0.
+func ParseODBC(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.Contains(s, " "):
+		return time.ParseInLocation(ODBCTimestamp, s, loc)
+	case strings.Contains(s, ":"):
+		return time.ParseInLocation(ODBCTime, s, loc)
+	default:
+		return time.ParseInLocation(ODBCDate, s, loc)
+	}
+}
+
+// FormatODBC returns the ODBC timestamp string of t in its location, with
+// fractional seconds when t has any.
+func FormatODBC(t time.Time) string {
+	return t.Format(ODBCTimestamp + ".999999999")
+}
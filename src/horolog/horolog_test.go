@@ -0,0 +1,103 @@
+package horolog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHorolog(t *testing.T) {
+	tm, err := Parse("67000,3600", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.June, 9, 1, 0, 0, 0, time.UTC), tm)
+	assert.Equal(t, "67000,3600", Format(tm))
+
+	tm, err = Parse("0", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, Epoch, tm)
+	assert.Equal(t, 0, Days(Epoch))
+	assert.Equal(t, -1, Days(Epoch.AddDate(0, 0, -1)))
+	assert.Equal(t, 132000, Days(DateOf(132000, time.UTC)))
+
+	for _, h := range []string{"", "abc", "1,abc", "1,86400", "1,-1"} {
+		_, err = Parse(h, time.UTC)
+		assert.ErrorIs(t, err, ErrInvalidHorolog, h)
+	}
+}
+
+func TestHorologLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	// $HOROLOG is wall-clock time in the given location, including on the
+	// day daylight saving time starts
+	tm, err := Parse("67000,43200", ny)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.June, 9, 12, 0, 0, 0, ny), tm)
+	assert.Equal(t, "67000,43200", Format(tm))
+	assert.Equal(t, "67000,57600", Format(tm.UTC()))
+
+	dst := time.Date(2024, time.March, 10, 12, 0, 0, 0, ny)
+	assert.Equal(t, dst, must(Parse(Format(dst), ny)))
+}
+
+func TestZTimestamp(t *testing.T) {
+	tm, err := ParseZTimestamp("67000,3600.123", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.June, 9, 1, 0, 0, 123000000, time.UTC), tm)
+	assert.Equal(t, "67000,3600.123", FormatZTimestamp(tm))
+
+	local := time.FixedZone("UTC+2", 2*60*60)
+	tm, err = ParseZTimestamp("67000,3600.5", local)
+	assert.NoError(t, err)
+	assert.Equal(t, local, tm.Location())
+	assert.Equal(t, 3, tm.Hour())
+	assert.Equal(t, "67000,3600.5", FormatZTimestamp(tm))
+}
+
+func TestPosix(t *testing.T) {
+	values := map[int64]time.Time{
+		1154679522636970432:  time.Date(2025, time.September, 16, 10, 20, 30, 123456000, time.UTC),
+		1154679647833814674:  time.Date(2025, time.September, 17, 21, 7, 6, 967698000, time.UTC),
+		-6947328004811081856: time.Date(1025, time.September, 16, 10, 20, 30, 0, time.UTC),
+	}
+	for value, tm := range values {
+		assert.Equal(t, tm, FromPosix(value, time.UTC))
+		assert.Equal(t, value, ToPosix(tm))
+	}
+	for _, tm := range []time.Time{
+		time.Unix(0, 0), time.Unix(-1, 0), time.Date(1840, time.December, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(9999, time.December, 31, 23, 59, 59, 999999000, time.UTC),
+	} {
+		assert.True(t, tm.Equal(FromPosix(ToPosix(tm), time.UTC)), tm)
+	}
+}
+
+func TestODBC(t *testing.T) {
+	tm, err := ParseODBC("2024-06-09 01:00:00.25", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.June, 9, 1, 0, 0, 250000000, time.UTC), tm)
+	assert.Equal(t, "2024-06-09 01:00:00.25", FormatODBC(tm))
+
+	tm, err = ParseODBC("2024-06-09", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.June, 9, 0, 0, 0, 0, time.UTC), tm)
+	assert.Equal(t, "2024-06-09 00:00:00", FormatODBC(tm))
+
+	tm, err = ParseODBC("13:45:30", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(0, time.January, 1, 13, 45, 30, 0, time.UTC), tm)
+	assert.Equal(t, 49530.0, Seconds(tm))
+
+	_, err = ParseODBC("not a date", time.UTC)
+	assert.Error(t, err)
+}
+
+func must(t time.Time, err error) time.Time {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
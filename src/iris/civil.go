@@ -0,0 +1,141 @@
+package iris
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/caretdev/go-irisnative/src/horolog"
+)
+
+// timeLayout formats the fractional seconds of a time of day, if any.
+const timeLayout = horolog.ODBCTime + ".999999999"
+
+// Date is a calendar date without a time of day or time zone, as stored in
+// DATE columns.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of t in its location.
+func DateOf(t time.Time) Date {
+	var d Date
+	d.Year, d.Month, d.Day = t.Date()
+	return d
+}
+
+// ParseDate parses an ODBC date string, "2006-01-02".
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(horolog.ODBCDate, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return DateOf(t), nil
+}
+
+// In returns the midnight of the date in loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// IsZero reports whether d is the zero value.
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// String returns the date in ODBC format, "2006-01-02".
+func (d Date) String() string {
+	return d.In(time.UTC).Format(horolog.ODBCDate)
+}
+
+// Scan implements sql.Scanner for time.Time and string values.
+func (d *Date) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case time.Time:
+		*d = DateOf(v)
+	case string:
+		*d, err = ParseDate(v)
+	case []byte:
+		*d, err = ParseDate(string(v))
+	case nil:
+		*d = Date{}
+	default:
+		err = fmt.Errorf("cannot scan %T into Date", src)
+	}
+	return
+}
+
+// Value implements driver.Valuer, sending the date in ODBC format.
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// TimeOfDay is a time of day without a date or time zone, as stored in TIME
+// columns.
+type TimeOfDay struct {
+	Hour       int
+	Minute     int
+	Second     int
+	Nanosecond int
+}
+
+// TimeOfDayOf returns the time of day of t in its location.
+func TimeOfDayOf(t time.Time) TimeOfDay {
+	return TimeOfDay{t.Hour(), t.Minute(), t.Second(), t.Nanosecond()}
+}
+
+// ParseTimeOfDay parses an ODBC time string, "15:04:05" with optional
+// fractional seconds.
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse(horolog.ODBCTime, s)
+	if err != nil {
+		return TimeOfDay{}, err
+	}
+	return TimeOfDayOf(t), nil
+}
+
+// On returns the time of day on the given date in loc.
+func (t TimeOfDay) On(d Date, loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, t.Hour, t.Minute, t.Second, t.Nanosecond, loc)
+}
+
+// Seconds returns the number of seconds since midnight.
+func (t TimeOfDay) Seconds() float64 {
+	return horolog.Seconds(t.time())
+}
+
+// String returns the time in ODBC format, "15:04:05" followed by the
+// fractional seconds, if any.
+func (t TimeOfDay) String() string {
+	return t.time().Format(timeLayout)
+}
+
+// time returns the time of day on January 1 of year 0 in UTC, as
+// horolog.TimeOf and time.Parse do.
+func (t TimeOfDay) time() time.Time {
+	return t.On(Date{Year: 0, Month: time.January, Day: 1}, time.UTC)
+}
+
+// Scan implements sql.Scanner for time.Time and string values.
+func (t *TimeOfDay) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case time.Time:
+		*t = TimeOfDayOf(v)
+	case string:
+		*t, err = ParseTimeOfDay(v)
+	case []byte:
+		*t, err = ParseTimeOfDay(string(v))
+	case nil:
+		*t = TimeOfDay{}
+	default:
+		err = fmt.Errorf("cannot scan %T into TimeOfDay", src)
+	}
+	return
+}
+
+// Value implements driver.Valuer, sending the time in ODBC format.
+func (t TimeOfDay) Value() (driver.Value, error) {
+	return t.String(), nil
+}
@@ -0,0 +1,42 @@
+package iris
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDate(t *testing.T) {
+	d, err := ParseDate("2024-06-09")
+	assert.NoError(t, err)
+	assert.Equal(t, Date{2024, time.June, 9}, d)
+	assert.Equal(t, "2024-06-09", d.String())
+	value, err := d.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-06-09", value)
+
+	var scanned Date
+	assert.NoError(t, scanned.Scan(time.Date(2024, time.June, 9, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, d, scanned)
+	assert.NoError(t, scanned.Scan(nil))
+	assert.True(t, scanned.IsZero())
+	assert.Error(t, scanned.Scan(42))
+}
+
+func TestTimeOfDay(t *testing.T) {
+	tod, err := ParseTimeOfDay("13:45:30.25")
+	assert.NoError(t, err)
+	assert.Equal(t, TimeOfDay{13, 45, 30, 250000000}, tod)
+	assert.Equal(t, "13:45:30.25", tod.String())
+	assert.Equal(t, 49530.25, tod.Seconds())
+	assert.Equal(t, "08:00:00", TimeOfDay{Hour: 8}.String())
+
+	var scanned TimeOfDay
+	assert.NoError(t, scanned.Scan(time.Date(0, time.January, 1, 13, 45, 30, 250000000, time.UTC)))
+	assert.Equal(t, tod, scanned)
+	assert.Equal(t,
+		time.Date(2024, time.June, 9, 13, 45, 30, 250000000, time.UTC),
+		tod.On(Date{2024, time.June, 9}, time.UTC),
+	)
+}
@@ -0,0 +1,95 @@
+package iris
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// UUID is a 128-bit GUID, as stored in %Library.UniqueIdentifier and GUID
+// columns.
+type UUID [16]byte
+
+// ParseUUID parses a GUID in the canonical form,
+// "6F9619FF-8B86-D011-B42D-00C04FC964FF", in either case, optionally in braces
+// or with a "urn:uuid:" prefix, or as 32 hexadecimal digits.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	text := s
+	if len(text) == 45 && strings.EqualFold(text[:9], "urn:uuid:") {
+		text = text[9:]
+	} else if len(text) == 38 && text[0] == '{' && text[37] == '}' {
+		text = text[1:37]
+	}
+	switch len(text) {
+	case 36:
+		if text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+			return u, fmt.Errorf("invalid UUID %q", s)
+		}
+		text = text[:8] + text[9:13] + text[14:18] + text[19:23] + text[24:]
+	case 32:
+	default:
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(text)); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// IsZero reports whether u is the nil UUID.
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// String returns the UUID in the canonical form IRIS generates, with
+// uppercase digits: "6F9619FF-8B86-D011-B42D-00C04FC964FF".
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return strings.ToUpper(string(b[:]))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *UUID) UnmarshalText(text []byte) (err error) {
+	*u, err = ParseUUID(string(text))
+	return
+}
+
+// Scan implements sql.Scanner for GUID strings and 16-byte binary values.
+func (u *UUID) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case string:
+		*u, err = ParseUUID(v)
+	case []byte:
+		if len(v) == len(u) {
+			copy(u[:], v)
+			return nil
+		}
+		*u, err = ParseUUID(string(v))
+	case nil:
+		*u = UUID{}
+	default:
+		err = fmt.Errorf("cannot scan %T into UUID", src)
+	}
+	return
+}
+
+// Value implements driver.Valuer, sending the UUID in canonical form.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
@@ -0,0 +1,49 @@
+package iris
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUID(t *testing.T) {
+	expected := UUID{0x6f, 0x96, 0x19, 0xff, 0x8b, 0x86, 0xd0, 0x11, 0xb4, 0x2d, 0x00, 0xc0, 0x4f, 0xc9, 0x64, 0xff}
+	for _, s := range []string{
+		"6F9619FF-8B86-D011-B42D-00C04FC964FF",
+		"6f9619ff-8b86-d011-b42d-00c04fc964ff",
+		"{6F9619FF-8B86-D011-B42D-00C04FC964FF}",
+		"urn:uuid:6f9619ff-8b86-d011-b42d-00c04fc964ff",
+		"6F9619FF8B86D011B42D00C04FC964FF",
+	} {
+		u, err := ParseUUID(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, u, s)
+	}
+	for _, s := range []string{"", "6F9619FF-8B86-D011-B42D", "6F9619FF+8B86-D011-B42D-00C04FC964FF", "XX9619FF-8B86-D011-B42D-00C04FC964FF"} {
+		_, err := ParseUUID(s)
+		assert.Error(t, err, s)
+	}
+
+	assert.Equal(t, "6F9619FF-8B86-D011-B42D-00C04FC964FF", expected.String())
+	value, err := expected.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "6F9619FF-8B86-D011-B42D-00C04FC964FF", value)
+
+	var scanned UUID
+	assert.NoError(t, scanned.Scan("6f9619ff-8b86-d011-b42d-00c04fc964ff"))
+	assert.Equal(t, expected, scanned)
+	assert.NoError(t, scanned.Scan(expected[:]))
+	assert.Equal(t, expected, scanned)
+	assert.NoError(t, scanned.Scan(nil))
+	assert.True(t, scanned.IsZero())
+	assert.Error(t, scanned.Scan(42))
+	assert.Error(t, scanned.Scan("not a uuid"))
+
+	data, err := json.Marshal(expected)
+	require.NoError(t, err)
+	assert.Equal(t, `"6F9619FF-8B86-D011-B42D-00C04FC964FF"`, string(data))
+	require.NoError(t, json.Unmarshal(data, &scanned))
+	assert.Equal(t, expected, scanned)
+}
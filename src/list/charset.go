@@ -0,0 +1,89 @@
+package list
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// NewCharset returns the Charset of an 8-bit encoding from golang.org/x/text,
+// such as charmap.Windows1251.
+func NewCharset(enc encoding.Encoding) Charset {
+	return textCharset{enc}
+}
+
+type textCharset struct {
+	enc encoding.Encoding
+}
+
+func (c textCharset) Encode(s string) ([]byte, error) {
+	data, err := c.enc.NewEncoder().Bytes([]byte(s))
+	if err == nil {
+		return data, nil
+	}
+	// Report the first character the encoding cannot represent
+	for i, r := range s {
+		if _, rerr := c.enc.NewEncoder().String(string(r)); rerr != nil {
+			return nil, fmt.Errorf("%w: %q at offset %d", ErrNotRepresentable, r, i)
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNotRepresentable, err)
+}
+
+func (c textCharset) Decode(data []byte) (string, error) {
+	s, err := c.enc.NewDecoder().Bytes(data)
+	return string(s), err
+}
+
+// localeCharsets maps the language part of IRIS locale names to the 8-bit
+// character sets of the locale: the ISO-8859 one for locales ending in "8" and
+// the Windows one for locales ending in "w".
+var localeCharsets = map[string][2]Charset{
+	"enu": western,
+	"eng": western,
+	"deu": western,
+	"fra": western,
+	"esp": western,
+	"ita": western,
+	"ptb": western,
+	"nld": western,
+	"dan": western,
+	"fin": western,
+	"nor": western,
+	"sve": western,
+	"csy": central,
+	"plk": central,
+	"hun": central,
+	"rus": cyrillic,
+	"ukr": cyrillic,
+	"ell": {NewCharset(charmap.ISO8859_7), NewCharset(charmap.Windows1253)},
+	"heb": {NewCharset(charmap.ISO8859_8), NewCharset(charmap.Windows1255)},
+}
+
+var (
+	western  = [2]Charset{Latin1, NewCharset(charmap.Windows1252)}
+	central  = [2]Charset{NewCharset(charmap.ISO8859_2), NewCharset(charmap.Windows1250)}
+	cyrillic = [2]Charset{NewCharset(charmap.ISO8859_5), NewCharset(charmap.Windows1251)}
+)
+
+// LocaleCharset returns the 8-bit character set of an IRIS locale, such as
+// "rusw" or "deu8", and false when the locale is not known.
+func LocaleCharset(locale string) (Charset, bool) {
+	locale = strings.ToLower(locale)
+	if len(locale) != 4 {
+		return nil, false
+	}
+	charsets, ok := localeCharsets[locale[:3]]
+	if !ok {
+		return nil, false
+	}
+	switch locale[3] {
+	case '8':
+		return charsets[0], true
+	case 'w':
+		return charsets[1], true
+	}
+	return nil, false
+}
@@ -0,0 +1,118 @@
+package list
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// ErrNotRepresentable is returned when a string contains characters the
+// server cannot store.
+var ErrNotRepresentable = errors.New("list: character not representable")
+
+// Charset converts between Go strings and the 8-bit data of LISTITEM_STRING
+// items.
+type Charset interface {
+	// Encode returns the 8-bit representation of s, or an error wrapping
+	// ErrNotRepresentable if s has characters outside the character set.
+	Encode(s string) ([]byte, error)
+	// Decode returns the string for 8-bit data.
+	Decode(data []byte) (string, error)
+}
+
+// Latin1 is the ISO 8859-1 character set, in which every byte is the Unicode
+// code point of the same value. It is the 8-bit character set of Unicode
+// servers.
+var Latin1 Charset = latin1{}
+
+type latin1 struct{}
+
+func (latin1) Encode(s string) ([]byte, error) {
+	data := make([]byte, 0, len(s))
+	for i, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("%w: %q at offset %d", ErrNotRepresentable, r, i)
+		}
+		data = append(data, byte(r))
+	}
+	return data, nil
+}
+
+func (latin1) Decode(data []byte) (string, error) {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes), nil
+}
+
+// Encoding controls how strings are stored in list items.
+type Encoding struct {
+	// Unicode allows strings the 8-bit character set cannot represent to be
+	// stored as LISTITEM_UNICODE (UTF-16LE). Servers without Unicode support
+	// only accept 8-bit strings.
+	Unicode bool
+	// Charset is the character set of 8-bit strings; nil means Latin1.
+	Charset Charset
+}
+
+// DefaultEncoding is the encoding of Unicode servers, used by NewListItem and
+// GetListItem.
+var DefaultEncoding = &Encoding{Unicode: true}
+
+func (e *Encoding) charset() Charset {
+	if e == nil || e.Charset == nil {
+		return Latin1
+	}
+	return e.Charset
+}
+
+// encodeString returns the item type and data for s: 8-bit when the
+// character set can represent it, UTF-16LE otherwise.
+func (e *Encoding) encodeString(s string) (ListItemType, []byte, error) {
+	data, err := e.charset().Encode(s)
+	if err == nil {
+		return LISTITEM_STRING, data, nil
+	}
+	if e != nil && !e.Unicode {
+		return 0, nil, err
+	}
+	utf16Runes := utf16.Encode([]rune(s))
+	data = make([]byte, len(utf16Runes)*2)
+	for i, r := range utf16Runes {
+		binary.LittleEndian.PutUint16(data[i*2:], r)
+	}
+	return LISTITEM_UNICODE, data, nil
+}
+
+// decodeString returns the string stored in a LISTITEM_STRING or
+// LISTITEM_UNICODE item.
+func (e *Encoding) decodeString(itemType ListItemType, data []byte) (string, error) {
+	if itemType != LISTITEM_UNICODE {
+		return e.charset().Decode(data)
+	}
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("list: invalid UTF-16 data length %d", len(data))
+	}
+	utf16Runes := make([]uint16, len(data)/2)
+	for i := range utf16Runes {
+		utf16Runes[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(utf16Runes)), nil
+}
+
+// NewListItem returns the list item for value, encoding strings with e.
+func (e *Encoding) NewListItem(value interface{}) (ListItem, error) {
+	li, err := newListItem(value, e)
+	li.encoding = e
+	return li, err
+}
+
+// GetListItem reads the list item at *offset in buffer and advances the
+// offset; strings of the item are decoded with e.
+func (e *Encoding) GetListItem(buffer []byte, offset *uint) ListItem {
+	li := GetListItem(buffer, offset)
+	li.encoding = e
+	return li
+}
@@ -0,0 +1,144 @@
+package list
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatin1ListItem(t *testing.T) {
+	li := NewListItem("café")
+	assert.Equal(t, []byte{0x06, 0x01, 0x63, 0x61, 0x66, 0xe9}, li.Dump())
+
+	li = NewListItem("ÿ\u0080")
+	assert.Equal(t, []byte{0x04, 0x01, 0xff, 0x80}, li.Dump())
+
+	li = NewListItem("€")
+	assert.Equal(t, []byte{0x04, 0x02, 0xac, 0x20}, li.Dump())
+
+	li = NewListItem("💻")
+	assert.Equal(t, []byte{0x06, 0x02, 0x3d, 0xd8, 0xbb, 0xdc}, li.Dump())
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"test",
+		"café",
+		"naïve façade",
+		"Größe",
+		"\u0080 ÿ",
+		"тест",
+		"€100",
+		"日本語",
+		"💻🚀",
+		"mixed é and ж",
+		"\x00",
+	}
+	for _, val := range values {
+		li := NewListItem(val)
+		var offset uint
+		read := GetListItem(li.Dump(), &offset)
+		var v string
+		assert.NoError(t, read.Get(&v), val)
+		if val == "" {
+			assert.Equal(t, "", v)
+			continue
+		}
+		assert.Equal(t, val, v, val)
+		assert.Equal(t, uint(len(li.Dump())), offset, val)
+	}
+}
+
+func TestDecodeLatin1(t *testing.T) {
+	var offset uint
+	li := GetListItem([]byte{0x06, 0x01, 0x63, 0x61, 0x66, 0xe9}, &offset)
+	var v string
+	assert.NoError(t, li.Get(&v))
+	assert.Equal(t, "café", v)
+
+	offset = 0
+	li = GetListItem([]byte{0x04, 0x01, 0xc3, 0xa9}, &offset)
+	assert.NoError(t, li.Get(&v))
+	assert.Equal(t, "Ã©", v)
+}
+
+func TestNonUnicodeEncoding(t *testing.T) {
+	enc := &Encoding{}
+
+	li, err := enc.NewListItem("café")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x06, 0x01, 0x63, 0x61, 0x66, 0xe9}, li.Dump())
+
+	_, err = enc.NewListItem("тест")
+	assert.ErrorIs(t, err, ErrNotRepresentable)
+
+	ref := "💻"
+	_, err = enc.NewListItem(&ref)
+	assert.ErrorIs(t, err, ErrNotRepresentable)
+
+	var offset uint
+	li = enc.GetListItem([]byte{0x06, 0x02, 0x42, 0x04, 0x35, 0x04}, &offset)
+	var v string
+	assert.NoError(t, li.Get(&v))
+	assert.Equal(t, "те", v)
+}
+
+func TestInvalidUnicodeData(t *testing.T) {
+	var offset uint
+	li := GetListItem([]byte{0x05, 0x02, 0x42, 0x04, 0x35}, &offset)
+	var v string
+	assert.Error(t, li.Get(&v))
+}
+
+func TestLocaleCharset(t *testing.T) {
+	charset, ok := LocaleCharset("RUSW")
+	assert.True(t, ok)
+	data, err := charset.Encode("Привет")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xcf, 0xf0, 0xe8, 0xe2, 0xe5, 0xf2}, data)
+	s, err := charset.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Привет", s)
+
+	_, err = charset.Encode("abc€😀")
+	assert.ErrorIs(t, err, ErrNotRepresentable)
+	assert.ErrorContains(t, err, "'😀' at offset 6")
+
+	// The last letter picks ISO-8859 or Windows code pages
+	charset, ok = LocaleCharset("rus8")
+	assert.True(t, ok)
+	data, err = charset.Encode("Привет")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xbf, 0xe0, 0xd8, 0xd2, 0xd5, 0xe2}, data)
+	charset, ok = LocaleCharset("deu8")
+	assert.True(t, ok)
+	assert.Equal(t, Latin1, charset)
+	charset, ok = LocaleCharset("deuw")
+	assert.True(t, ok)
+	data, err = charset.Encode("5 €")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x35, 0x20, 0x80}, data)
+	charset, ok = LocaleCharset("plkw")
+	assert.True(t, ok)
+	data, err = charset.Encode("ś")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x9c}, data)
+
+	_, ok = LocaleCharset("xx")
+	assert.False(t, ok)
+	_, ok = LocaleCharset("zzzw")
+	assert.False(t, ok)
+	_, ok = LocaleCharset("deux")
+	assert.False(t, ok)
+}
+
+func TestUnsupportedListItem(t *testing.T) {
+	_, err := DefaultEncoding.NewListItem(struct{}{})
+	assert.Error(t, err)
+	_, err = NewList(map[string]int{})
+	assert.Error(t, err)
+	// The legacy constructor keeps storing the formatted value
+	li := NewListItem(struct{}{})
+	assert.Equal(t, []byte{0x04, 0x01, '{', '}'}, li.Dump())
+}
@@ -0,0 +1,198 @@
+package list
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrOverflow is returned when a value does not fit the destination type.
+var ErrOverflow = errors.New("list: value out of range")
+
+// Integer items store the value, or for negative values its one's
+// complement, in little-endian order without trailing padding bytes. The
+// payload may be longer than 8 bytes.
+
+// trimInt removes the padding bytes that do not change the value.
+func trimInt(data []byte, negative bool) []byte {
+	var pad byte
+	if negative {
+		pad = 0xff
+	}
+	for len(data) > 0 && data[len(data)-1] == pad {
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
+// getUint64 decodes a LISTITEM_POSINT payload; ok is false when the value
+// does not fit a uint64.
+func getUint64(data []byte) (value uint64, ok bool) {
+	data = trimInt(data, false)
+	if len(data) > 8 {
+		return 0, false
+	}
+	var temp [8]byte
+	copy(temp[:], data)
+	return binary.LittleEndian.Uint64(temp[:]), true
+}
+
+// getInt64 decodes a LISTITEM_POSINT or LISTITEM_NEGINT payload; ok is false
+// when the value does not fit an int64.
+func getInt64(data []byte, negative bool) (value int64, ok bool) {
+	data = trimInt(data, negative)
+	if len(data) > 8 {
+		return 0, false
+	}
+	var temp [8]byte
+	copy(temp[:], data)
+	if negative {
+		for i := range data {
+			temp[i] ^= 0xff
+		}
+	}
+	u := binary.LittleEndian.Uint64(temp[:])
+	if u > math.MaxInt64 {
+		return 0, false
+	}
+	if negative {
+		return -int64(u) - 1, true
+	}
+	return int64(u), true
+}
+
+// getBigInt decodes an integer payload of any length.
+func getBigInt(data []byte, negative bool) *big.Int {
+	if value, ok := getInt64(data, negative); ok {
+		return big.NewInt(value)
+	}
+	bigEndian := make([]byte, len(data))
+	for i, b := range data {
+		if negative {
+			b ^= 0xff
+		}
+		bigEndian[len(data)-1-i] = b
+	}
+	value := new(big.Int).SetBytes(bigEndian)
+	if negative {
+		value.Neg(value.Add(value, big.NewInt(1)))
+	}
+	return value
+}
+
+// asBigInt returns the integer value of the item. Decimals and floats are
+// truncated towards zero; strings must hold an integer.
+func (li *ListItem) asBigInt() (*big.Int, error) {
+	if li.isNull {
+		return new(big.Int), nil
+	}
+	switch li.itemType {
+	case LISTITEM_STRING, LISTITEM_UNICODE:
+		str, err := li.getString()
+		if err != nil {
+			return nil, err
+		}
+		value, ok := new(big.Int).SetString(strings.TrimPrefix(str, "+"), 10)
+		if !ok {
+			return nil, fmt.Errorf("list: invalid integer %q", str)
+		}
+		return value, nil
+	case LISTITEM_POSINT:
+		return getBigInt(li.data, false), nil
+	case LISTITEM_NEGINT:
+		return getBigInt(li.data, true), nil
+	case LISTITEM_POSDECIMAL, LISTITEM_NEGDECIMAL:
+		return getDecimal(li.data, li.itemType == LISTITEM_NEGDECIMAL).BigInt(), nil
+	case LISTITEM_COMPACTFLOAT, LISTITEM_IEEEDOUBLE:
+		f, err := li.asFloat64()
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("list: cannot convert %v to an integer", f)
+		}
+		value, _ := big.NewFloat(f).Int(nil)
+		return value, nil
+	}
+	return nil, errors.New("not implemented")
+}
+
+// asInt64 returns the integer value of the item, or an error wrapping
+// ErrOverflow when it does not fit an int64.
+func (li *ListItem) asInt64() (int64, error) {
+	if !li.isNull && (li.itemType == LISTITEM_POSINT || li.itemType == LISTITEM_NEGINT) {
+		if value, ok := getInt64(li.data, li.itemType == LISTITEM_NEGINT); ok {
+			return value, nil
+		}
+	}
+	value, err := li.asBigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !value.IsInt64() {
+		return 0, fmt.Errorf("%w: %s does not fit int64", ErrOverflow, value)
+	}
+	return value.Int64(), nil
+}
+
+// asUint64 returns the integer value of the item, or an error wrapping
+// ErrOverflow when it is negative or does not fit a uint64.
+func (li *ListItem) asUint64() (uint64, error) {
+	if !li.isNull && li.itemType == LISTITEM_POSINT {
+		if value, ok := getUint64(li.data); ok {
+			return value, nil
+		}
+	}
+	value, err := li.asBigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !value.IsUint64() {
+		return 0, fmt.Errorf("%w: %s does not fit uint64", ErrOverflow, value)
+	}
+	return value.Uint64(), nil
+}
+
+// getSigned stores the integer value of li in v, checking its range.
+func getSigned[T int | int8 | int16 | int32 | int64](li *ListItem, v *T) error {
+	value, err := li.asInt64()
+	if err != nil {
+		return err
+	}
+	if int64(T(value)) != value {
+		return fmt.Errorf("%w: %d does not fit %T", ErrOverflow, value, *v)
+	}
+	*v = T(value)
+	return nil
+}
+
+// getUnsigned stores the integer value of li in v, checking its range.
+func getUnsigned[T uint | uint8 | uint16 | uint32 | uint64](li *ListItem, v *T) error {
+	value, err := li.asUint64()
+	if err != nil {
+		return err
+	}
+	if uint64(T(value)) != value {
+		return fmt.Errorf("%w: %d does not fit %T", ErrOverflow, value, *v)
+	}
+	*v = T(value)
+	return nil
+}
+
+// getDecimal decodes the payload of a LISTITEM_POSDECIMAL or
+// LISTITEM_NEGDECIMAL item: a signed power of ten followed by the mantissa.
+func getDecimal(data []byte, negative bool) decimal.Decimal {
+	if len(data) == 0 {
+		return decimal.Zero
+	}
+	exponent := int32(int8(data[0]))
+	if mantissa, ok := getInt64(data[1:], negative); ok {
+		return decimal.New(mantissa, exponent)
+	}
+	return decimal.NewFromBigInt(getBigInt(data[1:], negative), exponent)
+}
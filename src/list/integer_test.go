@@ -0,0 +1,210 @@
+package list
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, dump []byte) ListItem {
+	t.Helper()
+	var offset uint
+	li := GetListItem(dump, &offset)
+	require.Equal(t, uint(len(dump)), offset)
+	return li
+}
+
+func TestInt64Boundaries(t *testing.T) {
+	for _, value := range []int64{0, 1, -1, 255, -256, math.MaxInt32, math.MinInt32, math.MaxInt64, math.MinInt64, math.MaxInt64 - 1, math.MinInt64 + 1} {
+		li := NewListItem(value)
+		li = decode(t, li.Dump())
+		var i64 int64
+		assert.NoError(t, li.Get(&i64))
+		assert.Equal(t, value, i64)
+		v, err := li.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, value, v)
+		var b big.Int
+		assert.NoError(t, li.Get(&b))
+		assert.Equal(t, big.NewInt(value), &b)
+	}
+}
+
+func TestUint64(t *testing.T) {
+	// 8-byte positive integers above math.MaxInt64
+	li := decode(t, []byte{0x0a, 0x04, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	var u uint64
+	assert.NoError(t, li.Get(&u))
+	assert.Equal(t, uint64(math.MaxUint64), u)
+	v, err := li.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), v)
+	var s string
+	assert.NoError(t, li.Get(&s))
+	assert.Equal(t, "18446744073709551615", s)
+	var i64 int64
+	assert.ErrorIs(t, li.Get(&i64), ErrOverflow)
+	var i int
+	assert.ErrorIs(t, li.Get(&i), ErrOverflow)
+
+	// Sent as a string, which IRIS reads as a number
+	li = NewListItem(uint64(math.MaxUint64))
+	assert.Equal(t, LISTITEM_STRING, li.Type())
+	u = 0
+	assert.NoError(t, li.Get(&u))
+	assert.Equal(t, uint64(math.MaxUint64), u)
+	li = NewListItem(uint64(math.MaxInt64))
+	assert.Equal(t, LISTITEM_POSINT, li.Type())
+}
+
+func TestBigInt(t *testing.T) {
+	// 2^64 as a 9-byte positive integer
+	li := decode(t, []byte{0x0b, 0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0x01})
+	expected, _ := new(big.Int).SetString("18446744073709551616", 10)
+	v, err := li.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, v)
+	var b big.Int
+	assert.NoError(t, li.Get(&b))
+	assert.Equal(t, expected, &b)
+	var d decimal.Decimal
+	assert.NoError(t, li.Get(&d))
+	assert.Equal(t, "18446744073709551616", d.String())
+	var f float64
+	assert.NoError(t, li.Get(&f))
+	assert.Equal(t, math.Pow(2, 64), f)
+	var u uint64
+	assert.ErrorIs(t, li.Get(&u), ErrOverflow)
+
+	// -(2^64) - 1 as a 9-byte negative integer
+	li = decode(t, []byte{0x0b, 0x05, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe})
+	expected, _ = new(big.Int).SetString("-18446744073709551617", 10)
+	v, err = li.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, v)
+	var s string
+	assert.NoError(t, li.Get(&s))
+	assert.Equal(t, "-18446744073709551617", s)
+	assert.ErrorIs(t, li.Get(&u), ErrOverflow)
+
+	// Padded payloads still fit
+	li = decode(t, []byte{0x0b, 0x04, 0x2a, 0, 0, 0, 0, 0, 0, 0, 0})
+	var i int
+	assert.NoError(t, li.Get(&i))
+	assert.Equal(t, 42, i)
+
+	// Decimal with a 9-byte mantissa
+	li = decode(t, []byte{0x0c, 0x06, 0xfe, 0, 0, 0, 0, 0, 0, 0, 0, 0x01})
+	assert.NoError(t, li.Get(&d))
+	assert.Equal(t, "184467440737095516.16", d.String())
+
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	li = NewListItem(huge)
+	li = decode(t, li.Dump())
+	assert.NoError(t, li.Get(&b))
+	assert.Equal(t, huge, &b)
+	li = NewListItem(big.NewInt(-5))
+	assert.Equal(t, LISTITEM_NEGINT, li.Type())
+}
+
+func TestIntOverflow(t *testing.T) {
+	li := NewListItem(300)
+	var i8 int8
+	assert.ErrorIs(t, li.Get(&i8), ErrOverflow)
+	assert.Equal(t, int8(0), i8)
+	var u8 uint8
+	assert.ErrorIs(t, li.Get(&u8), ErrOverflow)
+	var i16 int16
+	assert.NoError(t, li.Get(&i16))
+	assert.Equal(t, int16(300), i16)
+
+	li = NewListItem(-1)
+	var u uint
+	assert.ErrorIs(t, li.Get(&u), ErrOverflow)
+	var u32 uint32
+	assert.ErrorIs(t, li.Get(&u32), ErrOverflow)
+
+	li = NewListItem(int64(math.MaxInt32) + 1)
+	var i32 int32
+	assert.ErrorIs(t, li.Get(&i32), ErrOverflow)
+
+	li = NewListItem("99999999999999999999")
+	var i64 int64
+	assert.ErrorIs(t, li.Get(&i64), ErrOverflow)
+	var b big.Int
+	assert.NoError(t, li.Get(&b))
+	assert.Equal(t, "99999999999999999999", b.String())
+
+	var small []int8
+	assert.ErrorIs(t, Unmarshal(mustList(NewList(1000)), &small), ErrOverflow)
+}
+
+func FuzzGetListItem(f *testing.F) {
+	for _, v := range tests {
+		f.Add(v.dump)
+	}
+	for _, v := range longString {
+		f.Add(v)
+	}
+	f.Add([]byte{0x0b, 0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0x01})
+	f.Add([]byte{0x0c, 0x07, 0x80, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00})
+	f.Add([]byte{0x04, 0x08, 0x80, 0x7f})
+	f.Add([]byte{0x06, 0x02, 0x42, 0x04, 0x35, 0x04})
+	f.Add([]byte{0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x61, 0x62})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var offset uint
+		for offset < uint(len(data)) {
+			start := offset
+			li := GetListItem(data, &offset)
+			if offset == start {
+				break
+			}
+			if offset > uint(len(data)) {
+				t.Fatalf("offset %d past the end of %d bytes", offset, len(data))
+			}
+			// None of the conversions may panic
+			li.Value()
+			li.asString()
+			li.asInt64()
+			li.asUint64()
+			li.asBigInt()
+			li.asFloat64()
+			li.asDecimal()
+
+			// Items survive a round trip
+			var again uint
+			dump := li.Dump()
+			li2 := GetListItem(dump, &again)
+			if again != uint(len(dump)) || li2.IsNull() != li.IsNull() {
+				t.Fatalf("round trip of % x failed: % x", data[start:offset], dump)
+			}
+			if !li.IsNull() && (li2.Type() != li.Type() || string(li2.data) != string(li.data)) {
+				t.Fatalf("round trip of % x changed the item: % x", data[start:offset], dump)
+			}
+		}
+	})
+}
+
+func FuzzIntegerRoundTrip(f *testing.F) {
+	f.Add(int64(0), uint64(0))
+	f.Add(int64(-1), uint64(math.MaxUint64))
+	f.Add(int64(math.MinInt64), uint64(math.MaxInt64)+1)
+	f.Fuzz(func(t *testing.T, i int64, u uint64) {
+		li := NewListItem(i)
+		li = decode(t, li.Dump())
+		var i2 int64
+		if err := li.Get(&i2); err != nil || i2 != i {
+			t.Fatalf("int64 %d decoded as %d: %v", i, i2, err)
+		}
+		li = NewListItem(u)
+		li = decode(t, li.Dump())
+		var u2 uint64
+		if err := li.Get(&u2); err != nil || u2 != u {
+			t.Fatalf("uint64 %d decoded as %d: %v", u, u2, err)
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package list
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// List is a $LIST value: the concatenation of its encoded items, as stored in
+// globals and %List properties. Nested lists are stored as string items
+// holding the encoded list.
+type List []byte
+
+var (
+	// ErrMalformed is returned for data that is not a valid $LIST.
+	ErrMalformed = errors.New("list: malformed $LIST data")
+	// ErrIndex is returned for positions past the end of a list.
+	ErrIndex = errors.New("list: index out of range")
+)
+
+// NewList returns the list of values, like $LISTBUILD. Values are encoded as
+// by NewListItem; List values become nested lists.
+func NewList(values ...interface{}) (List, error) {
+	var l List
+	if err := l.Append(values...); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Append adds values at the end of the list.
+func (l *List) Append(values ...interface{}) error {
+	for _, value := range values {
+		li, err := newListItem(value, nil)
+		if err != nil {
+			return err
+		}
+		*l = append(*l, li.Dump()...)
+	}
+	return nil
+}
+
+// Valid reports whether l is a well-formed list of known item types, like
+// $LISTVALID. The empty list is valid.
+func (l List) Valid() bool {
+	it := l.Iter()
+	for it.Next() {
+		li := it.Item()
+		if li.IsNull() {
+			continue
+		}
+		switch li.itemType {
+		case LISTITEM_STRING, LISTITEM_UNICODE, LISTITEM_POSINT, LISTITEM_NEGINT,
+			LISTITEM_POSDECIMAL, LISTITEM_NEGDECIMAL, LISTITEM_COMPACTFLOAT,
+			LISTITEM_IEEEDOUBLE, LISTITEM_OREF:
+		default:
+			return false
+		}
+	}
+	return it.Err() == nil
+}
+
+// Values returns the values of the items, as returned by ListItem.Value.
+func (l List) Values() ([]interface{}, error) {
+	var values []interface{}
+	if err := Unmarshal(l, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Scan implements sql.Scanner for list columns, which the driver returns as
+// []interface{}, and for raw $LIST data.
+func (l *List) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+	case []interface{}:
+		*l, err = NewList(v...)
+	case List:
+		*l = append(List(nil), v...)
+	case []byte:
+		*l = append(List(nil), v...)
+	case string:
+		*l = List(v)
+	default:
+		err = fmt.Errorf("list: cannot scan %T into List", src)
+	}
+	return
+}
+
+// Value implements driver.Valuer, sending the encoded list as binary data.
+func (l List) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return []byte(l), nil
+}
+
+// Iter returns an iterator over the items of the list.
+func (l List) Iter() *Iterator {
+	return &Iterator{list: l}
+}
+
+// Len returns the number of items in the list, like $LISTLENGTH.
+func (l List) Len() (n int, err error) {
+	it := l.Iter()
+	for it.Next() {
+		n++
+	}
+	return n, it.Err()
+}
+
+// Item returns the item at the 0-based position i, like $LIST(l, i+1).
+func (l List) Item(i int) (ListItem, error) {
+	it := l.Iter()
+	for n := 0; it.Next(); n++ {
+		if n == i {
+			return *it.Item(), nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		return ListItem{}, err
+	}
+	return ListItem{}, fmt.Errorf("%w: %d", ErrIndex, i)
+}
+
+// Get stores the item at the 0-based position i in value, like
+// $LISTGET(l, i+1). It reports false, leaving value unchanged, when the list
+// has no item at i or the item is undefined.
+func (l List) Get(i int, value interface{}) (bool, error) {
+	li, err := l.Item(i)
+	if errors.Is(err, ErrIndex) {
+		return false, nil
+	}
+	if err != nil || li.IsNull() {
+		return false, err
+	}
+	return true, li.Get(value)
+}
+
+// Find returns the 0-based position of the first item equal to value, or -1,
+// like $LISTFIND. Items are compared by their string value, so the number 1
+// matches the string "1".
+func (l List) Find(value interface{}) (int, error) {
+	target, err := newListItem(value, nil)
+	if err != nil {
+		return -1, err
+	}
+	want, err := target.asString()
+	if err != nil {
+		return -1, err
+	}
+	it := l.Iter()
+	for n := 0; it.Next(); n++ {
+		li := it.Item()
+		if li.IsNull() != target.IsNull() {
+			continue
+		}
+		got, err := li.asString()
+		if err != nil {
+			return -1, err
+		}
+		if got == want {
+			return n, nil
+		}
+	}
+	return -1, it.Err()
+}
+
+// Iterator walks the items of a List. The data of the items is not copied,
+// so it is only valid as long as the list is not modified.
+//
+//	it := l.Iter()
+//	for it.Next() {
+//		var name string
+//		it.Item().Get(&name)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	list   List
+	offset uint
+	item   ListItem
+	err    error
+}
+
+// Next advances to the next item and reports whether there is one.
+func (it *Iterator) Next() bool {
+	if it.err != nil || it.offset >= uint(len(it.list)) {
+		return false
+	}
+	offset := it.offset
+	it.item = GetListItem(it.list, &it.offset)
+	if it.offset == offset {
+		it.err = fmt.Errorf("%w at offset %d", ErrMalformed, offset)
+		return false
+	}
+	return true
+}
+
+// Item returns the current item.
+func (it *Iterator) Item() *ListItem {
+	return &it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
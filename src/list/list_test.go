@@ -0,0 +1,243 @@
+package list
+
+import (
+	"testing"
+
+	"github.com/caretdev/go-irisnative/src/iris"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewList(t *testing.T) {
+	l, err := NewList("a", 1, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, List{0x03, 0x01, 'a', 0x03, 0x04, 0x01, 0x01, 0x02, 0x01}, l)
+
+	nested, err := NewList(l, 2)
+	require.NoError(t, err)
+	assert.Equal(t, List{0x0b, 0x01, 0x03, 0x01, 'a', 0x03, 0x04, 0x01, 0x01, 0x02, 0x01, 0x03, 0x04, 0x02}, nested)
+
+	var inner List
+	ok, err := nested.Get(0, &inner)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, l, inner)
+
+	require.NoError(t, l.Append("b"))
+	n, err := l.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	n, err = List(nil).Len()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestListGet(t *testing.T) {
+	l, err := NewList("name", 42, nil, 1.5)
+	require.NoError(t, err)
+
+	var s string
+	ok, err := l.Get(0, &s)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "name", s)
+
+	var i int
+	ok, err = l.Get(1, &i)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, i)
+
+	s = "default"
+	ok, err = l.Get(2, &s)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "default", s)
+
+	ok, err = l.Get(10, &s)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	_, err = l.Item(10)
+	assert.ErrorIs(t, err, ErrIndex)
+	li, err := l.Item(3)
+	assert.NoError(t, err)
+	var f float64
+	assert.NoError(t, li.Get(&f))
+	assert.Equal(t, 1.5, f)
+}
+
+func TestListFind(t *testing.T) {
+	l, err := NewList("a", 1, nil, "b", "1")
+	require.NoError(t, err)
+	for value, want := range map[interface{}]int{"a": 0, 1: 1, "1": 1, "b": 3, "c": -1, nil: 2} {
+		n, err := l.Find(value)
+		assert.NoError(t, err)
+		assert.Equal(t, want, n, value)
+	}
+}
+
+func TestIterator(t *testing.T) {
+	l, err := NewList("x", "y", "z")
+	require.NoError(t, err)
+	var values []string
+	it := l.Iter()
+	for it.Next() {
+		var s string
+		assert.NoError(t, it.Item().Get(&s))
+		values = append(values, s)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"x", "y", "z"}, values)
+
+	// Items share the data of the list
+	it = l.Iter()
+	require.True(t, it.Next())
+	l[2] = 'w'
+	var s string
+	it.Item().Get(&s)
+	assert.Equal(t, "w", s)
+
+	it = List{0x03, 0x01, 'a', 0x05, 0x01, 'b'}.Iter()
+	assert.True(t, it.Next())
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), ErrMalformed)
+	_, err = List{0x03, 0x01, 'a', 0x05, 0x01}.Len()
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+type person struct {
+	Name    string
+	Age     int
+	Email   *string
+	Tags    []string
+	Balance decimal.Decimal
+	Ref     iris.Oref
+	secret  string
+	Skipped string `list:"-"`
+	Address struct {
+		City string
+		Zip  uint16
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	l, err := Marshal([]interface{}{"a", 1, nil, []int{2, 3}, []byte{0xff}})
+	require.NoError(t, err)
+	expected, _ := NewList("a", 1, nil, mustList(NewList(2, 3)), []byte{0xff})
+	assert.Equal(t, expected, l)
+
+	p := person{
+		Name:    "Smith,John",
+		Age:     42,
+		Tags:    []string{"x", "y"},
+		Balance: decimal.RequireFromString("12.34"),
+		Ref:     "1@Sample.Person",
+		secret:  "hidden",
+		Skipped: "skipped",
+	}
+	p.Address.City = "Boston"
+	p.Address.Zip = 2134
+	l, err = Marshal(&p)
+	require.NoError(t, err)
+	n, err := l.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, 7, n)
+
+	var decoded person
+	require.NoError(t, Unmarshal(l, &decoded))
+	p.secret = ""
+	p.Skipped = ""
+	assert.Equal(t, p, decoded)
+
+	email := "john@example.com"
+	p.Email = &email
+	l, err = Marshal(p)
+	require.NoError(t, err)
+	require.NoError(t, Unmarshal(l, &decoded))
+	assert.Equal(t, email, *decoded.Email)
+
+	_, err = Marshal(42)
+	assert.Error(t, err)
+	_, err = Marshal(nil)
+	assert.Error(t, err)
+	_, err = Marshal([]interface{}{map[string]int{}})
+	assert.Error(t, err)
+}
+
+func TestUnmarshal(t *testing.T) {
+	l, err := NewList("a", -5, nil, 2.5, mustList(NewList("b", 3)))
+	require.NoError(t, err)
+
+	var values []interface{}
+	require.NoError(t, Unmarshal(l, &values))
+	assert.Equal(t, []interface{}{"a", int64(-5), nil, 2.5, mustList(NewList("b", 3))}, values)
+
+	var dest interface{}
+	require.NoError(t, Unmarshal(l, &dest))
+	assert.Equal(t, values, dest)
+
+	var strs [2]string
+	require.NoError(t, Unmarshal(l, &strs))
+	assert.Equal(t, [2]string{"a", "-5"}, strs)
+
+	var tuple struct {
+		S string
+		I int8
+		N *int
+		F float32
+		L []interface{}
+	}
+	require.NoError(t, Unmarshal(l, &tuple))
+	assert.Equal(t, "a", tuple.S)
+	assert.Equal(t, int8(-5), tuple.I)
+	assert.Nil(t, tuple.N)
+	assert.Equal(t, float32(2.5), tuple.F)
+	assert.Equal(t, []interface{}{"b", int64(3)}, tuple.L)
+
+	var unsigned []uint
+	assert.Error(t, Unmarshal(mustList(NewList(-1)), &unsigned))
+	var small []int8
+	assert.Error(t, Unmarshal(mustList(NewList(300)), &small))
+	var nested [][]int
+	assert.Error(t, Unmarshal(mustList(NewList(1)), &nested))
+	assert.Error(t, Unmarshal(l, tuple))
+	assert.Error(t, Unmarshal(List{0x05, 0x01}, &values))
+}
+
+func mustList(l List, err error) List {
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func TestListScan(t *testing.T) {
+	l := mustList(NewList("a", 1))
+	values, err := l.Values()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", int64(1)}, values)
+
+	var scanned List
+	require.NoError(t, scanned.Scan(values))
+	assert.Equal(t, l, scanned)
+	raw := append([]byte(nil), l...)
+	require.NoError(t, scanned.Scan(raw))
+	raw[2] = 'b'
+	assert.Equal(t, l, scanned)
+	require.NoError(t, scanned.Scan(string(l)))
+	assert.Equal(t, l, scanned)
+	require.NoError(t, scanned.Scan(nil))
+	assert.Nil(t, scanned)
+	assert.Error(t, scanned.Scan(42))
+	assert.Error(t, scanned.Scan([]interface{}{map[string]int{}}))
+
+	value, err := l.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(l), value)
+	value, err = List(nil).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
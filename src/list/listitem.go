@@ -5,11 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"strconv"
-	"unicode/utf16"
 	"unsafe"
 
 	"github.com/caretdev/go-irisnative/src/iris"
+	"github.com/shopspring/decimal"
 )
 
 type ListItemType byte
@@ -26,12 +27,22 @@ const (
 	LISTITEM_OREF         ListItemType = 0x19
 )
 
+// Lengths of list items: one byte up to maxShortSize, then three bytes (0
+// and a 16-bit length) up to maxLongSize, then seven bytes (0, 0, 0 and a
+// 32-bit length). The long lengths count the type byte as well.
+const (
+	maxShortSize = 253
+	maxLongSize  = 0xfffe
+)
+
 type ListItem struct {
-	size     uint16
+	size     uint32
 	itemType ListItemType
 	data     []byte
 	isNull   bool
 	byRef    bool
+	// encoding decodes the strings of the item; nil means DefaultEncoding
+	encoding *Encoding
 }
 
 func (li *ListItem) IsNull() bool {
@@ -50,45 +61,26 @@ func (li *ListItem) Type() ListItemType {
 	return li.itemType
 }
 
-var scale = []float64{
-	1.0, 10.0, 100.0, 1000.0, 10000.0, 100000.0, 1000000.0, 1.0e7, 1.0e8, 1.0e9,
-	1.0e10, 1.0e11, 1.0e12, 1.0e13, 1.0e14, 1.0e15, 1.0e16, 1.0e17, 1.0e18, 1.0e19,
-	1.0e20, 1.0e21, 1.0e22, 9.999999999999999e22, 1.0e24, 1.0e25, 1.0e26, 1.0e27, 1.0e28, 1.0e29,
-	1.0e30, 1.0e31, 1.0e32, 1.0e33, 1.0e34, 1.0e35, 1.0e36, 1.0e37, 1.0e38, 1.0e39,
-	1.0e40, 1.0e41, 1.0e42, 1.0e43, 1.0e44, 1.0e45, 1.0e46, 1.0e47, 1.0e48, 1.0e49,
-	1.0e50, 1.0e51, 1.0e52, 1.0e53, 1.0e54, 1.0e55, 1.0e56, 1.0e57, 1.0e58, 1.0e59,
-	1.0e60, 1.0e61, 1.0e62, 1.0e63, 1.0e64, 1.0e65, 1.0e66, 1.0e67, 1.0e68, 1.0e69,
-	1.0e70, 1.0e71, 1.0e72, 1.0e73, 1.0e74, 1.0e75, 1.0e76, 1.0e77, 1.0e78, 1.0e79,
-	1.0e80, 1.0e81, 1.0e82, 1.0e83, 1.0e84, 1.0e85, 1.0e86, 1.0e87, 1.0e88, 1.0e89,
-	1.0e90, 1.0e91, 1.0e92, 1.0e93, 1.0e94, 1.0e95, 1.0e96, 1.0e97, 1.0e98, 1.0e99,
-	1.0e100, 1.0e101, 1.0e102, 1.0e103, 1.0e104, 1.0e105, 1.0e106, 1.0e107, 1.0e108, 1.0e109,
-	1.0e110, 1.0e111, 1.0e112, 1.0e113, 1.0e114, 1.0e115, 1.0e116, 1.0e117, 1.0e118, 1.0e119,
-	1.0e120, 1.0e121, 1.0e122, 1.0e123, 1.0e124, 1.0e125, 1.0e126, 1.0e127, 1.0e-128, 1.0e-127,
-	1.0e-126, 1.0e-125, 1.0e-124, 1.0e-123, 1.0e-122, 1.0e-121, 1.0e-120, 1.0e-119, 1.0e-118, 1.0e-117,
-	1.0e-116, 1.0e-115, 1.0e-114, 1.0e-113, 1.0e-112, 1.0e-111, 1.0e-110, 1.0e-109, 1.0e-108, 1.0e-107,
-	1.0e-106, 1.0e-105, 1.0e-104, 1.0e-103, 1.0e-102, 1.0e-101, 1.0e-100, 1.0e-99, 1.0e-98, 1.0e-97,
-	1.0e-96, 1.0e-95, 1.0e-94, 1.0e-93, 1.0e-92, 1.0e-91, 1.0e-90, 1.0e-89, 1.0e-88, 1.0e-87,
-	1.0e-86, 1.0e-85, 1.0e-84, 1.0e-83, 1.0e-82, 1.0e-81, 1.0e-80, 1.0e-79, 1.0e-78, 1.0e-77,
-	1.0e-76, 1.0e-75, 1.0e-74, 1.0e-73, 1.0e-72, 1.0e-71, 1.0e-70, 1.0e-69, 1.0e-68, 1.0e-67,
-	1.0e-66, 1.0e-65, 1.0e-64, 1.0e-63, 1.0e-62, 1.0e-61, 1.0e-60, 1.0e-59, 1.0e-58, 1.0e-57,
-	1.0e-56, 1.0e-55, 1.0e-54, 1.0e-53, 1.0e-52, 1.0e-51, 1.0e-50, 1.0e-49, 1.0e-48, 1.0e-47,
-	1.0e-46, 1.0e-45, 1.0e-44, 1.0e-43, 1.0e-42, 1.0e-41, 1.0e-40, 1.0e-39, 1.0e-38, 1.0e-37,
-	1.0e-36, 1.0e-35, 1.0e-34, 1.0e-33, 1.0e-32, 1.0e-31, 1.0e-30, 1.0e-29, 1.0e-28, 1.0e-27,
-	1.0e-26, 1.0e-25, 1.0e-24, 1.0e-23, 1.0e-22, 1.0e-21, 1.0e-20, 1.0e-19, 1.0e-18, 1.0e-17,
-	1.0e-16, 1.0e-15, 1.0e-14, 1.0e-13, 1.0e-12, 1.0e-11, 1.0e-10, 1.0e-9, 1.0e-8, 1.0e-7,
-	1.0e-6, 1.0e-5, 1.0e-4, 0.001, 0.01, 0.1}
+// IsByRef reports whether the item is marked as passed by reference, as
+// object references and output arguments are. Type returns the type without
+// the by-reference flag.
+func (li *ListItem) IsByRef() bool {
+	return li.byRef
+}
 
 func (listItem *ListItem) Dump() []byte {
 	if listItem.isNull {
 		return []byte{1}
 	}
-	var dump = make([]byte, 0)
-	if listItem.size > 253 {
-		size := listItem.size + 1
+	var dump = make([]byte, 0, listItem.size+7)
+	switch {
+	case listItem.size > maxLongSize:
+		dump = append(dump, 0, 0, 0)
+		dump = binary.LittleEndian.AppendUint32(dump, listItem.size+1)
+	case listItem.size > maxShortSize:
 		dump = append(dump, 0)
-		dump = append(dump, byte((size)&0xff))
-		dump = append(dump, byte((size>>8)&0xff))
-	} else {
+		dump = binary.LittleEndian.AppendUint16(dump, uint16(listItem.size+1))
+	default:
 		dump = append(dump, byte(listItem.size+2))
 	}
 	dump = append(dump, byte(listItem.itemType))
@@ -99,36 +91,49 @@ func (listItem *ListItem) Dump() []byte {
 func GetListItem(buffer []byte, ooffset *uint) ListItem {
 	var byRef = false
 	var isNull = false
-	var size uint16 = 0
+	var size uint32 = 0
 	var itemType byte = 0
 	offset := *ooffset
 
 	if offset >= uint(len(buffer)) {
-		return ListItem{size, ListItemType(itemType), []byte{}, true, false}
+		return ListItem{size, ListItemType(itemType), []byte{}, true, false, nil}
 	}
 
 	switch buffer[offset] {
 	case 0:
 		if offset+2 >= uint(len(buffer)) {
-			return ListItem{size, ListItemType(itemType), []byte{}, true, false}
+			return ListItem{size, ListItemType(itemType), []byte{}, true, false, nil}
 		}
-		size = uint16((buffer[offset+1] & 0xff))
-		size |= ((uint16(buffer[offset+2]) & 0xff) << 8)
-		size -= 1
+		size = uint32(binary.LittleEndian.Uint16(buffer[offset+1:]))
 		offset += 3
+		if size == 0 {
+			if offset+3 >= uint(len(buffer)) {
+				return ListItem{size, ListItemType(itemType), []byte{}, true, false, nil}
+			}
+			size = binary.LittleEndian.Uint32(buffer[offset:])
+			offset += 4
+		}
+		if size == 0 {
+			return ListItem{size, ListItemType(itemType), []byte{}, true, false, nil}
+		}
+		size -= 1
 		if offset >= uint(len(buffer)) {
-			return ListItem{size, ListItemType(itemType), []byte{}, true, false}
+			return ListItem{size, ListItemType(itemType), []byte{}, true, false, nil}
 		}
 		itemType = buffer[offset]
 		offset += 1
+		if itemType >= 32 && itemType < 64 {
+			itemType = itemType - 32
+			byRef = true
+		}
 	case 1:
 		isNull = true
 		offset += 1
 	default:
-		size = uint16(buffer[offset]) - 2
+		size = uint32(buffer[offset]) - 2
 		offset += 1
 		if offset >= uint(len(buffer)) {
-			return ListItem{size, ListItemType(itemType), []byte{}, true, false}
+			return ListItem{size, ListItemType(itemType), []byte{}, true, false, nil}
 		}
 		itemType = buffer[offset]
 		offset += 1
@@ -140,27 +145,38 @@ func GetListItem(buffer []byte, ooffset *uint) ListItem {
 	var data = []byte{}
 	if size > 0 {
 		if offset+uint(size) > uint(len(buffer)) {
-			return ListItem{size, ListItemType(itemType), []byte{}, true, false}
+			return ListItem{size, ListItemType(itemType), []byte{}, true, false, nil}
 		}
 		data = buffer[offset : offset+uint(size)]
 	}
 	offset += uint(size)
 	*ooffset = offset
-	return ListItem{size, ListItemType(itemType), data, isNull, byRef}
+	return ListItem{size, ListItemType(itemType), data, isNull, byRef, nil}
 }
 
+// NewListItem returns the list item for value, with strings encoded for
+// Unicode servers. Values of unsupported types are stored as their %v
+// formatting; use Encoding.NewListItem to have them reported as errors.
 func NewListItem(value interface{}) ListItem {
+	listItem, err := newListItem(value, nil)
+	if err != nil {
+		listItem, _ = newListItem(fmt.Sprintf("%v", value), nil)
+	}
+	return listItem
+}
+
+func newListItem(value interface{}, enc *Encoding) (ListItem, error) {
 	var itemType ListItemType = 0
-	var size uint16 = 0
+	var size uint32 = 0
 	var data = make([]byte, 0)
 	var isNull = false
 	var byRef = false
 
 	switch v := value.(type) {
 	case *string:
-		var listItem = NewListItem(*v)
+		listItem, err := newListItem(*v, enc)
 		listItem.byRef = true
-		return listItem
+		return listItem, err
 	case int, int8, int16, int32, int64:
 		var ival int64
 		switch i := v.(type) {
@@ -201,6 +217,11 @@ func NewListItem(value interface{}) ListItem {
 		case uint64:
 			uval = u
 		}
+		if uval > math.MaxInt64 {
+			// IRIS integers are signed 64-bit, larger numbers are sent as
+			// canonical numeric strings
+			return newListItem(strconv.FormatUint(uval, 10), enc)
+		}
 		itemType = 4
 		temp := uval
 		for temp > 0 {
@@ -251,6 +272,36 @@ func NewListItem(value interface{}) ListItem {
 				binary.LittleEndian.PutUint64(data, bits)
 			}
 		}
+	case *big.Int:
+		if v == nil {
+			return newListItem(nil, enc)
+		}
+		if v.IsInt64() {
+			return newListItem(v.Int64(), enc)
+		}
+		return newListItem(v.String(), enc)
+	case big.Int:
+		return newListItem(&v, enc)
+	case decimal.Decimal:
+		coefficient := v.Coefficient()
+		exponent := v.Exponent()
+		if !coefficient.IsInt64() || exponent < math.MinInt8 || exponent > math.MaxInt8 {
+			// Does not fit the scaled integer form, let the server parse it
+			return newListItem(v.String(), enc)
+		}
+		mantissa := coefficient.Int64()
+		itemType = LISTITEM_POSDECIMAL
+		var base int64 = 0
+		if mantissa < 0 {
+			itemType = LISTITEM_NEGDECIMAL
+			base = 0xff
+			mantissa = mantissa*-1 - 1
+		}
+		data = append(data, byte(int8(exponent)))
+		for mantissa > 0 {
+			data = append(data, byte((mantissa^base)&0xff))
+			mantissa = mantissa >> 8
+		}
 	case bool:
 		itemType = 4
 		if v {
@@ -259,28 +310,17 @@ func NewListItem(value interface{}) ListItem {
 			data = []byte{0x0}
 		}
 	case string:
-		itemType = 1
-		for _, r := range v {
-			if r > 255 {
-				itemType = 2
-				break
-			}
-		}
-		if itemType == 2 {
-			// Encode as UTF-16LE
-			runes := []rune(v)
-			utf16Runes := utf16.Encode(runes)
-			data = make([]byte, len(utf16Runes)*2)
-			for i, r := range utf16Runes {
-				data[i*2] = byte(r & 0xff)
-				data[i*2+1] = byte((r >> 8) & 0xff)
-			}
-		} else {
-			data = []byte(v)
+		var err error
+		if itemType, data, err = enc.encodeString(v); err != nil {
+			return ListItem{}, err
 		}
 	case []byte:
 		itemType = 1
 		data = v
+	case List:
+		// Nested lists are stored as 8-bit strings
+		itemType = 1
+		data = v
 	case nil:
 		isNull = true
 		// itemType = 1
@@ -290,68 +330,21 @@ func NewListItem(value interface{}) ListItem {
 		byRef = true
 		data = []byte(v)
 	default:
-		fmt.Printf("unknown: %#v %T\n", v, v)
-		itemType = 1
-		data = []byte(fmt.Sprintf("%v", v))
+		return ListItem{}, fmt.Errorf("list: unsupported type %T", v)
 	}
-	size = uint16(len(data))
+	size = uint32(len(data))
 	return ListItem{
 		size,
 		itemType,
 		data,
 		isNull,
 		byRef,
-	}
-}
-
-func (li *ListItem) getString() string {
-	if li.itemType == LISTITEM_UNICODE {
-		// Decode UTF-16LE
-		if len(li.data)%2 != 0 {
-			return string(li.data) // Fallback for invalid data
-		}
-		utf16Runes := make([]uint16, len(li.data)/2)
-		for i := 0; i < len(utf16Runes); i++ {
-			utf16Runes[i] = binary.LittleEndian.Uint16(li.data[i*2 : i*2+2])
-		}
-		runes := utf16.Decode(utf16Runes)
-		return string(runes)
-	} else {
-		return string(li.data)
-	}
-}
-
-func getPosInt(data []byte) int {
-	temp := make([]byte, 8)
-	copy(temp, data)
-	return int(binary.LittleEndian.Uint64(temp[:8]))
-}
-
-func getNegInt(data []byte) int {
-	temp := make([]byte, 8)
-	copy(temp, data)
-	for i := range data {
-		temp[i] ^= 0xff
-	}
-	return -int(binary.LittleEndian.Uint64(temp[:8]) + 1)
+		nil,
+	}, nil
 }
 
-func getPosFloat(data []byte) float64 {
-	scaleIndex := int(data[0])
-	if scaleIndex < 0 || scaleIndex >= len(scale) {
-		scaleIndex = 128 // Default to middle of array if out of bounds
-	}
-	d := scale[scaleIndex]
-	return float64(getPosInt(data[1:])) * d
-}
-
-func getNegFloat(data []byte) float64 {
-	scaleIndex := int(data[0])
-	if scaleIndex < 0 || scaleIndex >= len(scale) {
-		scaleIndex = 128 // Default to middle of array if out of bounds
-	}
-	d := scale[scaleIndex]
-	return float64(getNegInt(data[1:])) * d
+func (li *ListItem) getString() (string, error) {
+	return li.encoding.decodeString(li.itemType, li.data)
 }
 
 func (li *ListItem) asString() (value string, err error) {
@@ -361,15 +354,15 @@ func (li *ListItem) asString() (value string, err error) {
 	}
 	switch li.itemType {
 	case 1, 2, 25:
-		value = li.getString()
+		value, err = li.getString()
 	case 4:
-		value = fmt.Sprint(getPosInt(li.data))
+		value = getBigInt(li.data, false).String()
 	case 5:
-		value = fmt.Sprint(getNegInt(li.data))
+		value = getBigInt(li.data, true).String()
 	case 6:
-		value = fmt.Sprint(getPosFloat(li.data))
+		value = getDecimal(li.data, false).String()
 	case 7:
-		value = fmt.Sprint(getNegFloat(li.data))
+		value = getDecimal(li.data, true).String()
 	case 8, 9:
 		// Binary float types - convert to string via float64
 		f, err := li.asFloat64()
@@ -383,28 +376,6 @@ func (li *ListItem) asString() (value string, err error) {
 	return
 }
 
-func (li *ListItem) asInt() (value int, err error) {
-	if li.isNull {
-		value = 0
-		return
-	}
-	switch li.itemType {
-	case 1, 2:
-		value, err = strconv.Atoi(li.getString())
-	case 4:
-		value = getPosInt(li.data)
-	case 5:
-		value = getNegInt(li.data)
-	case 6:
-		value = int(getPosFloat(li.data))
-	case 7:
-		value = int(getNegFloat(li.data))
-	default:
-		err = errors.New("not implemented")
-	}
-	return
-}
-
 func (li *ListItem) asFloat64() (value float64, err error) {
 	if li.isNull {
 		value = 0
@@ -412,18 +383,22 @@ func (li *ListItem) asFloat64() (value float64, err error) {
 	}
 	switch li.itemType {
 	case 1, 2:
-		value, err = strconv.ParseFloat(li.getString(), 64)
+		var str string
+		if str, err = li.getString(); err != nil {
+			return
+		}
+		value, err = strconv.ParseFloat(str, 64)
 		if err != nil {
 			return
 		}
 	case 4:
-		value = float64(getPosInt(li.data))
+		value, _ = new(big.Float).SetInt(getBigInt(li.data, false)).Float64()
 	case 5:
-		value = float64(getNegInt(li.data))
+		value, _ = new(big.Float).SetInt(getBigInt(li.data, true)).Float64()
 	case 6:
-		value = getPosFloat(li.data)
+		value = getDecimal(li.data, false).InexactFloat64()
 	case 7:
-		value = getNegFloat(li.data)
+		value = getDecimal(li.data, true).InexactFloat64()
 	case 8:
 		if len(li.data) == 2 && li.data[0] == 0x80 {
 			if li.data[1] == 0x7F {
@@ -477,6 +452,42 @@ func (li *ListItem) asFloat64() (value float64, err error) {
 	return
 }
 
+func (li *ListItem) asDecimal() (value decimal.Decimal, err error) {
+	if li.isNull {
+		return
+	}
+	switch li.itemType {
+	case 1, 2:
+		var str string
+		if str, err = li.getString(); err != nil {
+			return
+		}
+		value, err = decimal.NewFromString(str)
+	case 4:
+		value = decimal.NewFromBigInt(getBigInt(li.data, false), 0)
+	case 5:
+		value = decimal.NewFromBigInt(getBigInt(li.data, true), 0)
+	case 6:
+		value = getDecimal(li.data, false)
+	case 7:
+		value = getDecimal(li.data, true)
+	case 8, 9:
+		var f float64
+		f, err = li.asFloat64()
+		if err != nil {
+			return
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			err = fmt.Errorf("cannot convert %v to decimal", f)
+			return
+		}
+		value = decimal.NewFromFloat(f)
+	default:
+		err = errors.New("not implemented")
+	}
+	return
+}
+
 type AnyType ListItem
 
 func (v *AnyType) Int() int {
@@ -496,47 +507,35 @@ func (li *ListItem) DataLength() int {
 func (li *ListItem) Get(value interface{}) (err error) {
 	switch v := value.(type) {
 	case *int:
-		*v, err = li.asInt()
+		err = getSigned(li, v)
 	case *bool:
-		var temp int
-		temp, err = li.asInt()
-		*v = temp != 0
+		var temp int64
+		if temp, err = li.asInt64(); err == nil {
+			*v = temp != 0
+		}
 	case *int8:
-		var temp int
-		temp, err = li.asInt()
-		*v = int8(temp)
+		err = getSigned(li, v)
 	case *int16:
-		var temp int
-		temp, err = li.asInt()
-		*v = int16(temp)
+		err = getSigned(li, v)
 	case *int32:
-		var temp int
-		temp, err = li.asInt()
-		*v = int32(temp)
+		err = getSigned(li, v)
 	case *int64:
-		var temp int
-		temp, err = li.asInt()
-		*v = int64(temp)
+		err = getSigned(li, v)
 	case *uint:
-		var temp int
-		temp, err = li.asInt()
-		*v = uint(temp)
+		err = getUnsigned(li, v)
 	case *uint8:
-		var temp int
-		temp, err = li.asInt()
-		*v = uint8(temp)
+		err = getUnsigned(li, v)
 	case *uint16:
-		var temp int
-		temp, err = li.asInt()
-		*v = uint16(temp)
+		err = getUnsigned(li, v)
 	case *uint32:
-		var temp int
-		temp, err = li.asInt()
-		*v = uint32(temp)
+		err = getUnsigned(li, v)
 	case *uint64:
-		var temp int
-		temp, err = li.asInt()
-		*v = uint64(temp)
+		err = getUnsigned(li, v)
+	case *big.Int:
+		var temp *big.Int
+		if temp, err = li.asBigInt(); err == nil {
+			v.Set(temp)
+		}
 	case *float64:
 		*v, err = li.asFloat64()
 	case *float32:
@@ -545,8 +544,14 @@ func (li *ListItem) Get(value interface{}) (err error) {
 		*v = float32(temp)
 	case *string:
 		*v, err = li.asString()
+	case *decimal.Decimal:
+		*v, err = li.asDecimal()
 	case *[]byte:
 		*v = li.data
+	case *List:
+		*v = List(li.data)
+	case *interface{}:
+		*v, err = li.Value()
 	case *iris.Oref:
 		var temp string
 		temp, err = li.asString()
@@ -556,3 +561,67 @@ func (li *ListItem) Get(value interface{}) (err error) {
 	}
 	return
 }
+
+// Value returns the item as the natural Go type of its list type:
+//
+//   - nil for undefined items
+//   - int64 for integers, or uint64 and *big.Int for integers that do not
+//     fit an int64
+//   - decimal.Decimal for scaled decimals and float64 for IEEE floats
+//   - string for Unicode strings and for 8-bit strings of text, which hold
+//     no control characters other than tabs and line breaks
+//   - for other 8-bit strings, a List when the data is a well-formed $LIST,
+//     and []byte otherwise; the "\x00" SQL empty-string marker is a string
+//   - iris.Oref for object references
+//
+// Every $LIST item starts with control characters, so text is never taken
+// for a nested list. Get into a string, []byte or List reads an item as such
+// regardless of its content.
+func (li *ListItem) Value() (interface{}, error) {
+	if li.isNull {
+		return nil, nil
+	}
+	switch li.itemType {
+	case LISTITEM_STRING:
+		if !isText(li.data) {
+			if List(li.data).Valid() {
+				return List(li.data), nil
+			}
+			return li.data, nil
+		}
+		return li.getString()
+	case LISTITEM_UNICODE:
+		return li.getString()
+	case LISTITEM_POSINT, LISTITEM_NEGINT:
+		negative := li.itemType == LISTITEM_NEGINT
+		if i, ok := getInt64(li.data, negative); ok {
+			return i, nil
+		}
+		if u, ok := getUint64(li.data); ok && !negative {
+			return u, nil
+		}
+		return getBigInt(li.data, negative), nil
+	case LISTITEM_POSDECIMAL, LISTITEM_NEGDECIMAL:
+		return li.asDecimal()
+	case LISTITEM_COMPACTFLOAT, LISTITEM_IEEEDOUBLE:
+		return li.asFloat64()
+	case LISTITEM_OREF:
+		s, err := li.getString()
+		return iris.Oref(s), err
+	}
+	return nil, fmt.Errorf("list: unknown item type %d", li.itemType)
+}
+
+// isText reports whether data holds no control characters other than tabs
+// and line breaks, or is the "\x00" empty-string marker.
+func isText(data []byte) bool {
+	if len(data) == 1 && data[0] == 0 {
+		return true
+	}
+	for _, b := range data {
+		if (b < 0x20 && b != '\t' && b != '\n' && b != '\r') || b == 0x7f {
+			return false
+		}
+	}
+	return true
+}
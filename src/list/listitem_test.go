@@ -3,8 +3,11 @@ package list
 import (
 	"math"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/caretdev/go-irisnative/src/iris"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -70,11 +73,14 @@ func TestListItem(t *testing.T) {
 }
 
 var longString = map[int][]byte{
-	253: {0xff, 0x01},
-	254: {0x00, 0xff, 0x00, 0x01},
-	255: {0x00, 0x00, 0x01, 0x01},
-	256: {0x00, 0x01, 0x01, 0x01},
-	512: {0x00, 0x01, 0x02, 0x01},
+	253:    {0xff, 0x01},
+	254:    {0x00, 0xff, 0x00, 0x01},
+	255:    {0x00, 0x00, 0x01, 0x01},
+	256:    {0x00, 0x01, 0x01, 0x01},
+	512:    {0x00, 0x01, 0x02, 0x01},
+	65534:  {0x00, 0xff, 0xff, 0x01},
+	65535:  {0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01},
+	100000: {0x00, 0x00, 0x00, 0xa1, 0x86, 0x01, 0x00, 0x01},
 }
 
 func TestLongListItem(t *testing.T) {
@@ -91,6 +97,42 @@ func TestLongListItem(t *testing.T) {
 	}
 }
 
+func TestExtendedListItem(t *testing.T) {
+	for _, l := range []int{0, 1, 252, 253, 254, 255, 65533, 65534, 65535, 65536, 100000, 3641144} {
+		val := strings.Repeat("x", l)
+		li := NewListItem(val)
+		dump := li.Dump()
+		next := NewListItem(42)
+		buffer := append(append([]byte{}, dump...), next.Dump()...)
+
+		var offset uint
+		li = GetListItem(buffer, &offset)
+		assert.Equal(t, uint(len(dump)), offset, l)
+		var v string
+		li.Get(&v)
+		if l == 0 {
+			assert.Equal(t, "", v)
+		} else {
+			assert.Equal(t, l, len(v))
+		}
+		var n int
+		li = GetListItem(buffer, &offset)
+		li.Get(&n)
+		assert.Equal(t, 42, n, l)
+	}
+
+	// Truncated extended length
+	var offset uint
+	li := GetListItem([]byte{0x00, 0x00, 0x00, 0xa1, 0x86}, &offset)
+	assert.True(t, li.IsNull())
+	assert.Equal(t, uint(0), offset)
+
+	// Truncated data
+	li = GetListItem([]byte{0x00, 0x00, 0x00, 0xa1, 0x86, 0x01, 0x00, 0x01, 'x'}, &offset)
+	assert.True(t, li.IsNull())
+	assert.Equal(t, uint(0), offset)
+}
+
 func TestIntListItem(t *testing.T) {
 	var li ListItem
 	li = NewListItem(int(1))
@@ -232,3 +274,101 @@ func TestSpecialIEEEValues(t *testing.T) {
 	v, _ = li.asFloat64()
 	assert.True(t, math.IsNaN(v))
 }
+
+func TestDecimalListItem(t *testing.T) {
+	var li ListItem
+	var d decimal.Decimal
+
+	li = NewListItem(decimal.RequireFromString("1.05"))
+	assert.Equal(t, []byte{0x4, 0x6, 0xfe, 0x69}, li.Dump())
+	assert.NoError(t, li.Get(&d))
+	assert.Equal(t, "1.05", d.String())
+
+	li = NewListItem(decimal.RequireFromString("-1.05"))
+	assert.Equal(t, []byte{0x4, 0x7, 0xfe, 0x97}, li.Dump())
+	assert.NoError(t, li.Get(&d))
+	assert.Equal(t, "-1.05", d.String())
+
+	// Values that lose cents as float64 stay exact
+	for _, val := range []string{"0.1", "0.3", "19.99", "-123456789.01", "92233720368547.75807", "1E+20", "0"} {
+		li = NewListItem(decimal.RequireFromString(val))
+		assert.NoError(t, li.Get(&d))
+		assert.True(t, decimal.RequireFromString(val).Equal(d), val)
+	}
+
+	// Too large for the scaled integer form, sent as text
+	li = NewListItem(decimal.RequireFromString("123456789012345678901234567890.5"))
+	assert.Equal(t, LISTITEM_STRING, li.Type())
+	assert.NoError(t, li.Get(&d))
+	assert.Equal(t, "123456789012345678901234567890.5", d.String())
+
+	var s string
+	var f float64
+	li = ListItem{size: 2, itemType: LISTITEM_POSDECIMAL, data: []byte{0xfe, 0x1d}}
+	assert.NoError(t, li.Get(&s))
+	assert.Equal(t, "0.29", s)
+	assert.NoError(t, li.Get(&f))
+	assert.Equal(t, 0.29, f)
+}
+
+func TestListItemValue(t *testing.T) {
+	nested, _ := NewList("a", 1)
+	values := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{nil, nil},
+		{42, int64(42)},
+		{-42, int64(-42)},
+		{0, int64(0)},
+		{decimal.RequireFromString("12.34"), decimal.RequireFromString("12.34")},
+		{1.5, 1.5},
+		{float32(0.5), 0.5},
+		{"test", "test"},
+		{"", ""},
+		{"café\ttab\r\n", "café\ttab\r\n"},
+		{"тест", "тест"},
+		{"\u00ff\u0080", "\u00ff\u0080"},
+		{[]byte{0x00, 0xff}, []byte{0x00, 0xff}},
+		{[]byte{0x01, 0x7f}, []byte{0x01, 0x7f}},
+		{"\x00", "\x00"},
+		{nested, nested},
+		{iris.Oref("1@Sample.Person"), iris.Oref("1@Sample.Person")},
+	}
+	for _, v := range values {
+		li := NewListItem(v.value)
+		var offset uint
+		li = GetListItem(li.Dump(), &offset)
+		value, err := li.Value()
+		assert.NoError(t, err, v.value)
+		assert.Equal(t, v.expected, value, v.value)
+
+		var dest interface{}
+		assert.NoError(t, li.Get(&dest), v.value)
+		assert.Equal(t, v.expected, dest, v.value)
+	}
+}
+
+func TestByRefListItem(t *testing.T) {
+	dumps := [][]byte{
+		{0x05, 0x21, 0x61, 0x62, 0x63},
+		append([]byte{0x00, 0x2d, 0x01, 0x21}, []byte(strings.Repeat("a", 300))...),
+		{0x03, 0x24, 0x2a},
+	}
+	expected := []interface{}{"abc", strings.Repeat("a", 300), int64(42)}
+	for i, dump := range dumps {
+		var offset uint
+		li := GetListItem(dump, &offset)
+		assert.True(t, li.IsByRef())
+		assert.Less(t, byte(li.Type()), byte(32))
+		value, err := li.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, expected[i], value)
+	}
+
+	s := "out"
+	li := NewListItem(&s)
+	assert.True(t, li.IsByRef())
+	li = NewListItem("in")
+	assert.False(t, li.IsByRef())
+}
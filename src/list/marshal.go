@@ -0,0 +1,279 @@
+package list
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/caretdev/go-irisnative/src/iris"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	orefType    = reflect.TypeOf(iris.Oref(""))
+	listType    = reflect.TypeOf(List(nil))
+	bigIntType  = reflect.TypeOf(big.Int{})
+)
+
+// Marshal returns the $LIST encoding of v, a slice, array or struct.
+//
+// Elements are encoded as by NewListItem. Nested slices, arrays and structs
+// become nested lists, except []byte which is stored as a string; nil
+// pointers, interfaces and slices become undefined items. The exported fields
+// of a struct are stored in order; fields tagged `list:"-"` are skipped.
+func Marshal(v interface{}) (List, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || !isListKind(rv.Type()) {
+		return nil, fmt.Errorf("list: cannot marshal %T", v)
+	}
+	return marshalList(rv)
+}
+
+func marshalList(rv reflect.Value) (List, error) {
+	var l List
+	if rv.Kind() == reflect.Struct {
+		for _, i := range listFields(rv.Type()) {
+			if err := marshalAppend(&l, rv.Field(i)); err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := marshalAppend(&l, rv.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func marshalAppend(l *List, rv reflect.Value) error {
+	value, err := marshalValue(rv)
+	if err != nil {
+		return err
+	}
+	return l.Append(value)
+}
+
+// marshalValue returns the value NewListItem encodes for rv.
+func marshalValue(rv reflect.Value) (interface{}, error) {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return marshalValue(rv.Elem())
+	}
+	switch rv.Type() {
+	case decimalType, orefType, listType:
+		return rv.Interface(), nil
+	case bigIntType:
+		value := rv.Interface().(big.Int)
+		return &value, nil
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.Float32:
+		return float32(rv.Float()), nil
+	case reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+		return marshalList(rv)
+	case reflect.Array, reflect.Struct:
+		return marshalList(rv)
+	}
+	return nil, fmt.Errorf("list: cannot marshal %s", rv.Type())
+}
+
+// Unmarshal decodes the $LIST data into v, a pointer to a slice, array,
+// struct or empty interface. It is the inverse of Marshal: slices get one
+// element per item, arrays and structs are filled in order and extra items are
+// ignored. Undefined items leave the zero value. An empty interface receives
+// []interface{} with the values of ListItem.Value.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("list: Unmarshal needs a non-nil pointer, not %T", v)
+	}
+	return unmarshalList(List(data), rv.Elem())
+}
+
+func unmarshalList(l List, rv reflect.Value) error {
+	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
+		var values []interface{}
+		if err := unmarshalList(l, reflect.ValueOf(&values).Elem()); err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(values))
+		return nil
+	}
+	if !isListKind(rv.Type()) {
+		return fmt.Errorf("list: cannot unmarshal a list into %s", rv.Type())
+	}
+	var fields []int
+	switch rv.Kind() {
+	case reflect.Slice:
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+	case reflect.Struct:
+		fields = listFields(rv.Type())
+	}
+	it := l.Iter()
+	for n := 0; it.Next(); n++ {
+		var elem reflect.Value
+		switch rv.Kind() {
+		case reflect.Slice:
+			rv.Set(reflect.Append(rv, reflect.Zero(rv.Type().Elem())))
+			elem = rv.Index(n)
+		case reflect.Array:
+			if n >= rv.Len() {
+				continue
+			}
+			elem = rv.Index(n)
+		case reflect.Struct:
+			if n >= len(fields) {
+				continue
+			}
+			elem = rv.Field(fields[n])
+		}
+		if err := unmarshalItem(it.Item(), elem); err != nil {
+			return fmt.Errorf("list: item %d: %w", n, err)
+		}
+	}
+	return it.Err()
+}
+
+func unmarshalItem(li *ListItem, rv reflect.Value) (err error) {
+	if li.IsNull() {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(rv.Type().Elem())
+		if err = unmarshalItem(li, elem.Elem()); err != nil {
+			return
+		}
+		rv.Set(elem)
+		return
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			break
+		}
+		var value interface{}
+		if value, err = li.Value(); err != nil {
+			return
+		}
+		if value != nil {
+			rv.Set(reflect.ValueOf(value))
+		}
+		return
+	}
+	switch rv.Type() {
+	case decimalType:
+		var d decimal.Decimal
+		d, err = li.asDecimal()
+		rv.Set(reflect.ValueOf(d))
+		return
+	case bigIntType:
+		var i *big.Int
+		if i, err = li.asBigInt(); err != nil {
+			return
+		}
+		rv.Set(reflect.ValueOf(*i))
+		return
+	}
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		rv.SetBytes(append([]byte{}, li.data...))
+		return
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		var s string
+		s, err = li.asString()
+		rv.SetString(s)
+	case reflect.Bool:
+		var i int64
+		i, err = li.asInt64()
+		rv.SetBool(i != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = li.asInt64(); err != nil {
+			return
+		}
+		if rv.OverflowInt(i) {
+			return fmt.Errorf("%w: %d does not fit %s", ErrOverflow, i, rv.Type())
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		if u, err = li.asUint64(); err != nil {
+			return
+		}
+		if rv.OverflowUint(u) {
+			return fmt.Errorf("%w: %d does not fit %s", ErrOverflow, u, rv.Type())
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = li.asFloat64(); err != nil {
+			return
+		}
+		if rv.Kind() == reflect.Float32 && math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return fmt.Errorf("%w: %v does not fit %s", ErrOverflow, f, rv.Type())
+		}
+		rv.SetFloat(f)
+	case reflect.Slice, reflect.Array, reflect.Struct:
+		if li.itemType != LISTITEM_STRING {
+			return fmt.Errorf("cannot unmarshal item of type %d into %s", li.itemType, rv.Type())
+		}
+		return unmarshalList(List(li.data), rv)
+	default:
+		return fmt.Errorf("cannot unmarshal into %s", rv.Type())
+	}
+	return
+}
+
+func isListKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	case reflect.Struct:
+		return t != decimalType && t != bigIntType
+	}
+	return false
+}
+
+// listFields returns the indexes of the struct fields stored in a list.
+func listFields(t reflect.Type) []int {
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("list") == "-" {
+			continue
+		}
+		fields = append(fields, i)
+	}
+	return fields
+}
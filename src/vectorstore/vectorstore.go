@@ -0,0 +1,410 @@
+// Package vectorstore is a small embedding store on top of IRIS VECTOR
+// columns and the database/sql driver.
+//
+// A Store keeps documents in one table: an ID, the content, an embedding and
+// any metadata columns declared when the store is created.
+//
+//	store, err := vectorstore.New(db, "Sample.Docs", 384,
+//		vectorstore.WithMetadata(vectorstore.Column{Name: "Category", Type: "VARCHAR(64)"}))
+//	err = store.CreateTable(ctx)
+//	err = store.CreateIndex(ctx)
+//	err = store.Upsert(ctx, vectorstore.Document{ID: "1", Content: text, Embedding: embedding,
+//		Metadata: map[string]interface{}{"Category": "news"}})
+//	results, err := store.Search(ctx, query, 5, vectorstore.Eq("Category", "news"))
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/caretdev/go-irisnative/src/connection"
+)
+
+// DB is the subset of *sql.DB, *sql.Conn and *sql.Tx a Store uses.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Metric is the similarity used to rank documents; higher scores are more
+// similar.
+type Metric int
+
+const (
+	// Cosine ranks by VECTOR_COSINE.
+	Cosine Metric = iota
+	// DotProduct ranks by VECTOR_DOT_PRODUCT, the same as Cosine for
+	// normalized embeddings and cheaper to compute.
+	DotProduct
+)
+
+func (m Metric) function() string {
+	if m == DotProduct {
+		return "VECTOR_DOT_PRODUCT"
+	}
+	return "VECTOR_COSINE"
+}
+
+func (m Metric) distance() string {
+	if m == DotProduct {
+		return "DotProduct"
+	}
+	return "Cosine"
+}
+
+// Column is a metadata column of the store. Type is the SQL type used in
+// CREATE TABLE, such as "VARCHAR(64)", "NUMERIC(10,2)" or "INTEGER"; it is a
+// type name with an optional length or precision and scale, nothing else.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Document is a stored text with its embedding. Metadata holds the values of
+// the metadata columns by name; missing columns are stored as NULL.
+type Document struct {
+	ID        string
+	Content   string
+	Embedding []float32
+	Metadata  map[string]interface{}
+}
+
+// Result is a document found by Search, with its similarity score.
+type Result struct {
+	ID       string
+	Content  string
+	Metadata map[string]interface{}
+	Score    float64
+}
+
+// ErrDimension is returned for embeddings whose length is not the dimension
+// of the store.
+var ErrDimension = errors.New("vectorstore: embedding dimension mismatch")
+
+// identifier matches the table and column names a Store accepts, optionally
+// qualified with a schema.
+var identifier = regexp.MustCompile(`^[A-Za-z%][A-Za-z0-9_]*(\.[A-Za-z%][A-Za-z0-9_]*)?$`)
+
+// columnType matches the metadata column types a Store accepts.
+var columnType = regexp.MustCompile(`^[A-Za-z]+(\(\d+(,\d+)?\))?$`)
+
+// txBeginner is implemented by *sql.DB and *sql.Conn, which Upsert uses to
+// store several documents in one transaction.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Store is an embedding store kept in one table.
+type Store struct {
+	db        DB
+	table     string
+	dimension int
+	metric    Metric
+	elemType  connection.VectorType
+	metadata  []Column
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithMetric sets the similarity used by Search and the index; the default is
+// Cosine.
+func WithMetric(metric Metric) Option {
+	return func(s *Store) {
+		s.metric = metric
+	}
+}
+
+// WithElementType sets the element type of the embedding column; the default
+// is DOUBLE. FLOAT halves the storage and needs IRIS 2025.1 or later.
+func WithElementType(elemType connection.VectorType) Option {
+	return func(s *Store) {
+		s.elemType = elemType
+	}
+}
+
+// WithMetadata declares the metadata columns of the store.
+func WithMetadata(columns ...Column) Option {
+	return func(s *Store) {
+		s.metadata = append(s.metadata, columns...)
+	}
+}
+
+// New returns the store of embeddings of the dimension in table.
+func New(db DB, table string, dimension int, opts ...Option) (*Store, error) {
+	s := &Store{
+		db:        db,
+		table:     table,
+		dimension: dimension,
+		elemType:  connection.VectorDouble,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if !identifier.MatchString(table) {
+		return nil, fmt.Errorf("vectorstore: invalid table name %q", table)
+	}
+	if dimension <= 0 {
+		return nil, fmt.Errorf("vectorstore: invalid dimension %d", dimension)
+	}
+	switch s.elemType {
+	case connection.VectorDouble, connection.VectorFloat:
+	default:
+		return nil, fmt.Errorf("vectorstore: unsupported element type %s", s.elemType)
+	}
+	for _, column := range s.metadata {
+		if !identifier.MatchString(column.Name) || strings.Contains(column.Name, ".") {
+			return nil, fmt.Errorf("vectorstore: invalid column name %q", column.Name)
+		}
+		switch strings.ToUpper(column.Name) {
+		case "ID", "CONTENT", "EMBEDDING", "SCORE":
+			return nil, fmt.Errorf("vectorstore: column name %q is reserved", column.Name)
+		}
+		if !columnType.MatchString(column.Type) {
+			return nil, fmt.Errorf("vectorstore: invalid type %q of column %s", column.Type, column.Name)
+		}
+	}
+	return s, nil
+}
+
+// CreateTable creates the table of the store.
+func (s *Store) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.createTableSQL())
+	return err
+}
+
+// CreateIndex creates an HNSW index on the embeddings, for the metric of the
+// store. It needs IRIS 2024.3 or later.
+func (s *Store) CreateIndex(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.createIndexSQL())
+	return err
+}
+
+// DropTable drops the table of the store, with its data and index.
+func (s *Store) DropTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "DROP TABLE "+s.table)
+	return err
+}
+
+// Upsert inserts the documents, replacing those with the same ID. Several
+// documents are stored in one transaction when the store was created on a
+// *sql.DB or *sql.Conn, so that either all of them or none are stored; on a
+// *sql.Tx they are part of that transaction.
+func (s *Store) Upsert(ctx context.Context, docs ...Document) error {
+	b, ok := s.db.(txBeginner)
+	if !ok || len(docs) < 2 {
+		return s.upsert(ctx, s.db, docs)
+	}
+	tx, err := b.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = s.upsert(ctx, tx, docs); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) upsert(ctx context.Context, db DB, docs []Document) error {
+	query := s.upsertSQL()
+	for _, doc := range docs {
+		args, err := s.upsertArgs(doc)
+		if err != nil {
+			return err
+		}
+		if _, err = db.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("vectorstore: upsert %q: %w", doc.ID, err)
+		}
+	}
+	return nil
+}
+
+// Delete removes the documents with the IDs, with a single statement.
+func (s *Store) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	_, err := s.db.ExecContext(ctx, s.deleteSQL(len(ids)), args...)
+	return err
+}
+
+// Search returns the k documents most similar to the embedding among those
+// matching all filters, most similar first.
+func (s *Store) Search(ctx context.Context, embedding []float32, k int, filters ...Filter) ([]Result, error) {
+	query, args, err := s.searchSQL(embedding, k, filters)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []Result
+	for rows.Next() {
+		var r Result
+		metadata := make([]interface{}, len(s.metadata))
+		// Content is a stream column, returned as a *connection.Stream when
+		// large with stream_lobs
+		dest := []interface{}{&r.ID, connection.ScanText(&r.Content), &r.Score}
+		for i := range metadata {
+			dest = append(dest, &metadata[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if len(s.metadata) > 0 {
+			r.Metadata = make(map[string]interface{}, len(s.metadata))
+			for i, column := range s.metadata {
+				r.Metadata[column.Name] = metadata[i]
+			}
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func (s *Store) vectorType() string {
+	return fmt.Sprintf("%s, %d", s.elemType, s.dimension)
+}
+
+func (s *Store) createTableSQL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %s (ID VARCHAR(255) PRIMARY KEY, Content LONGVARCHAR", s.table)
+	for _, column := range s.metadata {
+		fmt.Fprintf(&b, ", %s %s", column.Name, column.Type)
+	}
+	fmt.Fprintf(&b, ", Embedding VECTOR(%s))", s.vectorType())
+	return b.String()
+}
+
+func (s *Store) createIndexSQL() string {
+	name := s.table[strings.LastIndex(s.table, ".")+1:] + "HNSW"
+	return fmt.Sprintf("CREATE INDEX %s ON TABLE %s (Embedding) AS %%SQL.Index.HNSW(Distance='%s')",
+		name, s.table, s.metric.distance())
+}
+
+func (s *Store) upsertSQL() string {
+	columns := []string{"ID", "Content"}
+	for _, column := range s.metadata {
+		columns = append(columns, column.Name)
+	}
+	columns = append(columns, "Embedding")
+	return fmt.Sprintf("INSERT OR UPDATE INTO %s (%s) VALUES (%s)",
+		s.table, strings.Join(columns, ", "), placeholders(len(columns)))
+}
+
+func (s *Store) deleteSQL(n int) string {
+	return fmt.Sprintf("DELETE FROM %s WHERE ID IN (%s)", s.table, placeholders(n))
+}
+
+// placeholders returns n comma-separated parameter placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func (s *Store) upsertArgs(doc Document) ([]interface{}, error) {
+	embedding, err := s.embedding(doc.Embedding)
+	if err != nil {
+		return nil, err
+	}
+	for name := range doc.Metadata {
+		if !s.hasColumn(name) {
+			return nil, fmt.Errorf("vectorstore: unknown metadata column %q", name)
+		}
+	}
+	args := []interface{}{doc.ID, doc.Content}
+	for _, column := range s.metadata {
+		args = append(args, doc.Metadata[column.Name])
+	}
+	return append(args, embedding), nil
+}
+
+func (s *Store) searchSQL(embedding []float32, k int, filters []Filter) (string, []interface{}, error) {
+	if k <= 0 {
+		return "", nil, fmt.Errorf("vectorstore: invalid k %d", k)
+	}
+	vector, err := s.embedding(embedding)
+	if err != nil {
+		return "", nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "SELECT TOP %d ID, Content, %s(Embedding, ?) AS Score", k, s.metric.function())
+	for _, column := range s.metadata {
+		fmt.Fprintf(&b, ", %s", column.Name)
+	}
+	fmt.Fprintf(&b, " FROM %s", s.table)
+	args := []interface{}{vector}
+	for i, filter := range filters {
+		if !s.hasColumn(filter.Column) {
+			return "", nil, fmt.Errorf("vectorstore: unknown metadata column %q", filter.Column)
+		}
+		switch filter.Op {
+		case "=", "<>", "<", "<=", ">", ">=":
+		default:
+			return "", nil, fmt.Errorf("vectorstore: invalid operator %q", filter.Op)
+		}
+		if i == 0 {
+			b.WriteString(" WHERE ")
+		} else {
+			b.WriteString(" AND ")
+		}
+		fmt.Fprintf(&b, "%s %s ?", filter.Column, filter.Op)
+		args = append(args, filter.Value)
+	}
+	b.WriteString(" ORDER BY Score DESC")
+	return b.String(), args, nil
+}
+
+// embedding returns the embedding as a vector parameter, which the server
+// converts to the element type of the column.
+func (s *Store) embedding(embedding []float32) (connection.Vector, error) {
+	if len(embedding) != s.dimension {
+		return connection.Vector{}, fmt.Errorf("%w: %d, want %d", ErrDimension, len(embedding), s.dimension)
+	}
+	return connection.NewVector(embedding)
+}
+
+func (s *Store) hasColumn(name string) bool {
+	for _, column := range s.metadata {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter restricts Search to documents whose metadata column compares with
+// the value. Op is one of =, <>, <, <=, > and >=.
+type Filter struct {
+	Column string
+	Op     string
+	Value  interface{}
+}
+
+// Eq matches documents whose column equals value.
+func Eq(column string, value interface{}) Filter { return Filter{column, "=", value} }
+
+// Ne matches documents whose column differs from value.
+func Ne(column string, value interface{}) Filter { return Filter{column, "<>", value} }
+
+// Lt matches documents whose column is less than value.
+func Lt(column string, value interface{}) Filter { return Filter{column, "<", value} }
+
+// Le matches documents whose column is at most value.
+func Le(column string, value interface{}) Filter { return Filter{column, "<=", value} }
+
+// Gt matches documents whose column is greater than value.
+func Gt(column string, value interface{}) Filter { return Filter{column, ">", value} }
+
+// Ge matches documents whose column is at least value.
+func Ge(column string, value interface{}) Filter { return Filter{column, ">=", value} }
@@ -0,0 +1,83 @@
+package vectorstore
+
+import (
+	"testing"
+
+	"github.com/caretdev/go-irisnative/src/connection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, "Sample.Docs", 3)
+	assert.NoError(t, err)
+	_, err = New(nil, "Docs; DROP TABLE X", 3)
+	assert.Error(t, err)
+	_, err = New(nil, "Sample.Docs", 0)
+	assert.Error(t, err)
+	_, err = New(nil, "Sample.Docs", 3, WithMetadata(Column{"Bad Name", "INTEGER"}))
+	assert.Error(t, err)
+	_, err = New(nil, "Sample.Docs", 3, WithMetadata(Column{"Score", "INTEGER"}))
+	assert.Error(t, err)
+	_, err = New(nil, "Sample.Docs", 3, WithMetadata(Column{"Price", "NUMERIC(10,2)"}))
+	assert.NoError(t, err)
+	for _, typ := range []string{"", "INTEGER, Other INTEGER", "VARCHAR(64)) --", "VARCHAR(x)"} {
+		_, err = New(nil, "Sample.Docs", 3, WithMetadata(Column{"Category", typ}))
+		assert.Error(t, err, typ)
+	}
+	_, err = New(nil, "Sample.Docs", 3, WithElementType(connection.VectorString))
+	assert.Error(t, err)
+}
+
+func TestStoreSQL(t *testing.T) {
+	s, err := New(nil, "Sample.Docs", 3,
+		WithMetadata(Column{"Category", "VARCHAR(64)"}, Column{"Year", "INTEGER"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "CREATE TABLE Sample.Docs (ID VARCHAR(255) PRIMARY KEY, Content LONGVARCHAR, "+
+		"Category VARCHAR(64), Year INTEGER, Embedding VECTOR(DOUBLE, 3))", s.createTableSQL())
+	assert.Equal(t, "CREATE INDEX DocsHNSW ON TABLE Sample.Docs (Embedding) AS %SQL.Index.HNSW(Distance='Cosine')",
+		s.createIndexSQL())
+	assert.Equal(t, "INSERT OR UPDATE INTO Sample.Docs (ID, Content, Category, Year, Embedding) "+
+		"VALUES (?, ?, ?, ?, ?)", s.upsertSQL())
+	assert.Equal(t, "DELETE FROM Sample.Docs WHERE ID IN (?, ?, ?)", s.deleteSQL(3))
+
+	args, err := s.upsertArgs(Document{
+		ID:        "1",
+		Content:   "text",
+		Embedding: []float32{0.5, 1, -2},
+		Metadata:  map[string]interface{}{"Year": 2024},
+	})
+	require.NoError(t, err)
+	embedding := connection.Vector{Type: connection.VectorFloat, Values: []float32{0.5, 1, -2}}
+	assert.Equal(t, []interface{}{"1", "text", nil, 2024, embedding}, args)
+	_, err = s.upsertArgs(Document{ID: "1", Embedding: []float32{1}})
+	assert.ErrorIs(t, err, ErrDimension)
+	_, err = s.upsertArgs(Document{ID: "1", Embedding: []float32{1, 2, 3}, Metadata: map[string]interface{}{"Other": 1}})
+	assert.Error(t, err)
+
+	query, args, err := s.searchSQL([]float32{1, 0, 0}, 5, []Filter{Eq("Category", "news"), Ge("Year", 2020)})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT TOP 5 ID, Content, VECTOR_COSINE(Embedding, ?) AS Score, "+
+		"Category, Year FROM Sample.Docs WHERE Category = ? AND Year >= ? ORDER BY Score DESC", query)
+	vector := connection.Vector{Type: connection.VectorFloat, Values: []float32{1, 0, 0}}
+	assert.Equal(t, []interface{}{vector, "news", 2020}, args)
+
+	_, _, err = s.searchSQL([]float32{1, 0, 0}, 0, nil)
+	assert.Error(t, err)
+	_, _, err = s.searchSQL([]float32{1, 0, 0}, 5, []Filter{Eq("Missing", 1)})
+	assert.Error(t, err)
+	_, _, err = s.searchSQL([]float32{1, 0, 0}, 5, []Filter{{"Year", "= 1 OR 1 =", 1}})
+	assert.Error(t, err)
+}
+
+func TestDotProduct(t *testing.T) {
+	s, err := New(nil, "Docs", 2, WithMetric(DotProduct), WithElementType(connection.VectorFloat))
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE INDEX DocsHNSW ON TABLE Docs (Embedding) AS %SQL.Index.HNSW(Distance='DotProduct')",
+		s.createIndexSQL())
+	query, _, err := s.searchSQL([]float32{1, 0}, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT TOP 1 ID, Content, VECTOR_DOT_PRODUCT(Embedding, ?) AS Score "+
+		"FROM Docs ORDER BY Score DESC", query)
+}
@@ -1,10 +1,19 @@
 package tests_test
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
 
 	_ "github.com/caretdev/go-irisnative"
+	"github.com/caretdev/go-irisnative/src/connection"
+	"github.com/caretdev/go-irisnative/src/list"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -115,4 +124,406 @@ func TestStreams(t *testing.T) {
 		assert.Equal(t, []interface{}{1, "Test"}, []interface{}{id, data})
 
 	})
+	t.Run("lazy", func(t *testing.T) {
+		var err error
+		db := openDbWrapper(t, connectionString+"?stream_lobs=true")
+		defer closeDbWrapper(t, db)
+
+		_, err = db.Exec("create table testing_lazy_streams (ID identity, data LONGVARCHAR)")
+		require.NoError(t, err)
+		defer db.Exec("drop table testing_lazy_streams")
+
+		large := strings.Repeat("0123456789", 5000)
+		_, err = db.Exec("INSERT INTO testing_lazy_streams (data) VALUES ('Test')")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO testing_lazy_streams (data) VALUES (?)",
+			connection.CharacterStream{Reader: strings.NewReader(large)})
+		require.NoError(t, err)
+
+		// Small values scan into a string like without stream_lobs
+		var text string
+		err = db.QueryRow("select data from testing_lazy_streams where id = 1").Scan(&text)
+		require.NoError(t, err)
+		assert.Equal(t, "Test", text)
+
+		// Larger streams are read while the rows are open
+		rows, err := db.Query("select data from testing_lazy_streams where id = 2")
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		var stream connection.Stream
+		require.NoError(t, rows.Scan(&stream))
+		data, err := io.ReadAll(&stream)
+		require.NoError(t, err)
+		assert.Equal(t, large, string(data))
+		require.NoError(t, rows.Close())
+		_, err = stream.Read(make([]byte, 1))
+		assert.Error(t, err)
+
+		err = db.QueryRow("select data from testing_lazy_streams where id = 2").Scan(connection.ScanText(&text))
+		require.NoError(t, err)
+		assert.Equal(t, large, text)
+	})
+}
+
+func TestLastInsertId(t *testing.T) {
+	t.Run("with config", func(t *testing.T) {
+		var err error
+		db := openDbWrapper(t, connectionString+"?generated_keys=true")
+		defer closeDbWrapper(t, db)
+
+		_, err = db.Exec("create table testing_keys (ID identity, Name varchar(100))")
+		require.NoError(t, err)
+		defer db.Exec("drop table testing_keys")
+
+		var res sql.Result
+		res, err = db.Exec("INSERT INTO testing_keys (Name) VALUES (?)", "first")
+		require.NoError(t, err)
+		id, err := res.LastInsertId()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), id)
+
+		res, err = db.Exec("INSERT INTO testing_keys (Name) VALUES (?)", "second", "third")
+		require.NoError(t, err)
+		id, err = res.LastInsertId()
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), id)
+	})
+}
+
+func TestStreamParameters(t *testing.T) {
+	t.Run("with config", func(t *testing.T) {
+		var err error
+		db := openDbWrapper(t, connectionString+"?stream_threshold=32000")
+		defer closeDbWrapper(t, db)
+
+		_, err = db.Exec("create table testing_stream_params (ID identity, text LONGVARCHAR, bin LONGVARBINARY)")
+		require.NoError(t, err)
+		defer db.Exec("drop table testing_stream_params")
+
+		text := strings.Repeat("0123456789", 100000)
+		bin := bytes.Repeat([]byte{0, 1, 2, 3, 255}, 100000)
+		_, err = db.Exec("INSERT INTO testing_stream_params (text, bin) VALUES (?, ?)", text, bytes.NewReader(bin))
+		require.NoError(t, err)
+
+		var (
+			gotText string
+			gotBin  []byte
+		)
+		err = db.QueryRow("select text, bin from testing_stream_params").Scan(&gotText, &gotBin)
+		require.NoError(t, err)
+		assert.Equal(t, text, gotText)
+		assert.Equal(t, bin, gotBin)
+	})
+}
+
+func TestDecimal(t *testing.T) {
+	t.Run("with config", func(t *testing.T) {
+		var err error
+		db := openDbWrapper(t, connectionString)
+		defer closeDbWrapper(t, db)
+
+		_, err = db.Exec("create table testing_decimal (ID identity, amount NUMERIC(18,2))")
+		require.NoError(t, err)
+		defer db.Exec("drop table testing_decimal")
+
+		_, err = db.Exec("INSERT INTO testing_decimal (amount) VALUES (?)", decimal.RequireFromString("1234567890123.45"))
+		require.NoError(t, err)
+
+		var (
+			amount decimal.Decimal
+			text   string
+			float  float64
+		)
+		err = db.QueryRow("select amount, amount, amount from testing_decimal").Scan(&amount, &text, &float)
+		require.NoError(t, err)
+		assert.Equal(t, "1234567890123.45", amount.String())
+		assert.Equal(t, "1234567890123.45", text)
+		assert.Equal(t, 1234567890123.45, float)
+	})
+}
+
+func TestBinary(t *testing.T) {
+	t.Run("with config", func(t *testing.T) {
+		var err error
+		db := openDbWrapper(t, connectionString)
+		defer closeDbWrapper(t, db)
+
+		_, err = db.Exec("create table testing_binary (ID identity, data VARBINARY(100))")
+		require.NoError(t, err)
+		defer db.Exec("drop table testing_binary")
+
+		value := []byte{0x00, 0x01, 0x7f, 0x80, 0xc3, 0xa9, 0xff}
+		_, err = db.Exec("INSERT INTO testing_binary (data) VALUES (?)", value)
+		require.NoError(t, err)
+
+		var data []byte
+		rows, err := db.Query("select data from testing_binary")
+		require.NoError(t, err)
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		require.NoError(t, err)
+		assert.Equal(t, reflect.TypeOf([]byte{}), types[0].ScanType())
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&data))
+		assert.Equal(t, value, data)
+	})
+}
+
+func TestVector(t *testing.T) {
+	t.Run("with config", func(t *testing.T) {
+		var err error
+		db := openDbWrapper(t, connectionString)
+		defer closeDbWrapper(t, db)
+
+		_, err = db.Exec("create table testing_vector (ID identity, embedding VECTOR(DOUBLE, 3), counts VECTOR(INTEGER, 3))")
+		require.NoError(t, err)
+		defer db.Exec("drop table testing_vector")
+
+		embedding := connection.Vector{Type: connection.VectorDouble, Values: []float64{0.5, 1, -2}}
+		_, err = db.Exec("INSERT INTO testing_vector (embedding, counts) VALUES (?, ?)", embedding, []int64{1, 2, 3})
+		require.NoError(t, err)
+
+		rows, err := db.Query("select embedding, counts from testing_vector")
+		require.NoError(t, err)
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		require.NoError(t, err)
+		assert.Equal(t, "VECTOR", types[0].DatabaseTypeName())
+		assert.Equal(t, reflect.TypeOf([]float64{}), types[0].ScanType())
+		assert.Equal(t, reflect.TypeOf([]int64{}), types[1].ScanType())
+		require.True(t, rows.Next())
+		var (
+			values []float64
+			counts connection.Vector
+		)
+		require.NoError(t, rows.Scan(&values, &counts))
+		assert.Equal(t, []float64{0.5, 1, -2}, values)
+		assert.Equal(t, connection.Vector{Type: connection.VectorInteger, Values: []int64{1, 2, 3}}, counts)
+		require.NoError(t, rows.Close())
+
+		// A vector parameter compares with the column
+		var score float64
+		err = db.QueryRow("select VECTOR_COSINE(embedding, ?) from testing_vector", embedding).Scan(&score)
+		require.NoError(t, err)
+		assert.InDelta(t, 1, score, 1e-9)
+
+		// The element type and dimension come from the column metadata
+		conn, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer conn.Close()
+		err = conn.Raw(func(driverConn interface{}) error {
+			stmt, err := driverConn.(driver.Conn).Prepare("select embedding, counts from testing_vector")
+			if err != nil {
+				return err
+			}
+			defer stmt.Close()
+			rows, err := stmt.Query(nil)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			vectorRows := rows.(interface {
+				ColumnTypeVector(index int) (connection.VectorType, int, bool)
+			})
+			elemType, dimension, ok := vectorRows.ColumnTypeVector(0)
+			assert.True(t, ok)
+			assert.Equal(t, connection.VectorDouble, elemType)
+			assert.Equal(t, 3, dimension)
+			elemType, dimension, ok = vectorRows.ColumnTypeVector(1)
+			assert.True(t, ok)
+			assert.Equal(t, connection.VectorInteger, elemType)
+			assert.Equal(t, 3, dimension)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestLocaleStrings(t *testing.T) {
+	t.Run("with config", func(t *testing.T) {
+		var err error
+		// The charset only applies to servers without Unicode support
+		db := openDbWrapper(t, connectionString+"?charset=ISO-8859-5")
+		defer closeDbWrapper(t, db)
+
+		_, err = db.Exec("create table testing_locale (ID identity, name VARCHAR(100))")
+		require.NoError(t, err)
+		defer db.Exec("drop table testing_locale")
+
+		for _, value := range []string{"Größe €", "тест", "café"} {
+			_, err = db.Exec("INSERT INTO testing_locale (name) VALUES (?)", value)
+			require.NoError(t, err)
+			var name string
+			err = db.QueryRow("select name from testing_locale where name = ?", value).Scan(&name)
+			require.NoError(t, err)
+			assert.Equal(t, value, name)
+		}
+	})
+}
+
+func TestSliceExpansion(t *testing.T) {
+	t.Run("with config", func(t *testing.T) {
+		var err error
+		db := openDbWrapper(t, connectionString)
+		defer closeDbWrapper(t, db)
+
+		_, err = db.Exec("create table testing_slices (ID identity, name VARCHAR(100))")
+		require.NoError(t, err)
+		defer db.Exec("drop table testing_slices")
+		for _, name := range []string{"first", "second", "third"} {
+			_, err = db.Exec("INSERT INTO testing_slices (name) VALUES (?)", name)
+			require.NoError(t, err)
+		}
+
+		rows, err := db.Query("select name from testing_slices where id IN (?) and name <> ? order by id", []int{1, 3}, "")
+		require.NoError(t, err)
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var name string
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		require.NoError(t, rows.Err())
+		assert.Equal(t, []string{"first", "third"}, names)
+
+		var count int
+		err = db.QueryRow("select count(*) from testing_slices where name IN (?)", []string{"second", "other"}).Scan(&count)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		db := openDbWrapper(t, connectionString+"?expand_slices=false")
+		defer closeDbWrapper(t, db)
+
+		_, err := db.Query("select 1 where 1 IN (?)", []int{1, 2})
+		assert.Error(t, err)
+	})
+}
+
+func TestColumnTypes(t *testing.T) {
+	query := "select CAST(7 AS INTEGER), CAST(8 AS BIGINT), CAST(1.5 AS DOUBLE), CAST(2.5 AS REAL), CAST(1 AS BIT)"
+	t.Run("with config", func(t *testing.T) {
+		db := openDbWrapper(t, connectionString)
+		defer closeDbWrapper(t, db)
+
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		require.NoError(t, err)
+		assert.Equal(t, reflect.TypeOf(int64(0)), types[0].ScanType())
+		assert.Equal(t, reflect.TypeOf(int64(0)), types[1].ScanType())
+		assert.Equal(t, reflect.TypeOf(float64(0)), types[2].ScanType())
+		assert.Equal(t, reflect.TypeOf(float64(0)), types[3].ScanType())
+		assert.Equal(t, reflect.TypeOf(false), types[4].ScanType())
+		require.True(t, rows.Next())
+		values := make([]interface{}, 5)
+		dest := make([]interface{}, 5)
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		require.NoError(t, rows.Scan(dest...))
+		assert.Equal(t, []interface{}{int64(7), int64(8), 1.5, 2.5, true}, values)
+	})
+	t.Run("legacy", func(t *testing.T) {
+		db := openDbWrapper(t, connectionString+"?legacy_types=true")
+		defer closeDbWrapper(t, db)
+
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		require.NoError(t, err)
+		assert.Equal(t, reflect.TypeOf(0), types[0].ScanType())
+		assert.Equal(t, reflect.TypeOf(int64(0)), types[1].ScanType())
+		assert.Equal(t, reflect.TypeOf(float32(0)), types[3].ScanType())
+		require.True(t, rows.Next())
+		var integer, float, ignored interface{}
+		require.NoError(t, rows.Scan(&integer, &ignored, &ignored, &float, &ignored))
+		assert.Equal(t, 7, integer)
+		assert.Equal(t, float32(2.5), float)
+	})
+}
+
+func TestListColumns(t *testing.T) {
+	query := "select $LISTBUILD('a', 1, 2.5)"
+	t.Run("with config", func(t *testing.T) {
+		db := openDbWrapper(t, connectionString)
+		defer closeDbWrapper(t, db)
+
+		// Lists are strings unless scanned into a list.List
+		var l list.List
+		require.NoError(t, db.QueryRow(query).Scan(&l))
+		values, err := l.Values()
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", int64(1), 2.5}, values)
+		var value interface{}
+		require.NoError(t, db.QueryRow(query).Scan(&value))
+		assert.IsType(t, "", value)
+	})
+	t.Run("decoded", func(t *testing.T) {
+		db := openDbWrapper(t, connectionString+"?list_columns=true")
+		defer closeDbWrapper(t, db)
+
+		var value interface{}
+		require.NoError(t, db.QueryRow(query).Scan(&value))
+		assert.Equal(t, []interface{}{"a", int64(1), 2.5}, value)
+		require.NoError(t, db.QueryRow("select 'text'").Scan(&value))
+		assert.Equal(t, "text", value)
+	})
+}
+
+func TestFeatureOptions(t *testing.T) {
+	negotiated := func(t *testing.T, db *sql.DB) connection.FeatureOption {
+		conn, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer conn.Close()
+		var options connection.FeatureOption
+		err = conn.Raw(func(driverConn interface{}) error {
+			options = driverConn.(interface {
+				FeatureOptions() connection.FeatureOption
+			}).FeatureOptions()
+			return nil
+		})
+		require.NoError(t, err)
+		return options
+	}
+
+	t.Run("with config", func(t *testing.T) {
+		db := openDbWrapper(t, connectionString)
+		defer closeDbWrapper(t, db)
+		// The server grants at most the default options
+		assert.Zero(t, negotiated(t, db)&^connection.DefaultFeatureOptions)
+	})
+	t.Run("none", func(t *testing.T) {
+		db := openDbWrapper(t, connectionString+"?feature_options=none")
+		defer closeDbWrapper(t, db)
+		assert.Equal(t, connection.OptionNone, negotiated(t, db))
+
+		var value int
+		require.NoError(t, db.QueryRow("select 42").Scan(&value))
+		assert.Equal(t, 42, value)
+	})
+	t.Run("fast insert", func(t *testing.T) {
+		db, err := sql.Open("intersystems", connectionString+"?feature_options=fast_insert")
+		require.NoError(t, err)
+		defer db.Close()
+		assert.Error(t, db.Ping())
+	})
+}
+
+func TestClientIdentity(t *testing.T) {
+	t.Run("with config", func(t *testing.T) {
+		db := openDbWrapper(t, connectionString+"?application_name=billing&machine_name=node-1&os_user=svc")
+		defer closeDbWrapper(t, db)
+
+		var application, machine, user string
+		err := db.QueryRow("select ClientExecutableName, ClientNodeName, OSUserName from %SYS.ProcessQuery where Pid = $JOB").
+			Scan(&application, &machine, &user)
+		require.NoError(t, err)
+		assert.Equal(t, "billing", application)
+		assert.Equal(t, "node-1", machine)
+		assert.Equal(t, "svc", user)
+	})
 }
@@ -8,6 +8,7 @@ require (
 	github.com/caretdev/go-irisnative v0.0.0-00010101000000-000000000000
 	github.com/caretdev/testcontainers-iris-go v0.1.1
 	github.com/jmoiron/sqlx v1.4.0
+	github.com/shopspring/decimal v1.4.0
 	github.com/stretchr/testify v1.11.1
 	github.com/testcontainers/testcontainers-go v0.38.0
 )
@@ -64,6 +65,7 @@ require (
 	go.opentelemetry.io/otel/trace v1.38.0 // indirect
 	golang.org/x/crypto v0.39.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.30.0 // indirect
 	google.golang.org/grpc v1.75.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )